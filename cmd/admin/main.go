@@ -0,0 +1,103 @@
+package main
+
+import (
+	"acourse_tag_cart_bookmark_service/cmd/grpc_client"
+	"acourse_tag_cart_bookmark_service/pkg/admin"
+	"acourse_tag_cart_bookmark_service/pkg/config"
+	"acourse_tag_cart_bookmark_service/pkg/database"
+	"acourse_tag_cart_bookmark_service/pkg/database/migrations"
+	"acourse_tag_cart_bookmark_service/pkg/notifiers"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Inspect and fix the carts and bookmarks of users without a Mongo shell, e.g. 'admin -output json cart show user-1';
+// the exit status is 2 for a wrong command and 1 when the command fails
+func main() {
+
+	env := flag.String("env", ".env", "path of the env file")
+	output := flag.String("output", "table", "table or json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <command>\n\nflags:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output(), "\n"+admin.Usage)
+	}
+	flag.Parse()
+
+	if *output != "table" && *output != "json" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	//Create Config Instance
+	cfg := config.Construct(*env)
+
+	//Connecting Databases
+	db := database.Construct(cfg)
+	db.Prepare()
+
+	//An empty APP_MAX_BOOKMARK_ITEMS or APP_MAX_CART_ITEMS keeps the repository default
+	maxBookmarkItems, _ := strconv.ParseInt(cfg.GetAppConfig()["MAX_BOOKMARK_ITEMS"], 10, 64)
+	maxCartItems, _ := strconv.ParseInt(cfg.GetAppConfig()["MAX_CART_ITEMS"], 10, 64)
+
+	bookmarkRepo := repositories.ConstructBookmarkDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"]), maxBookmarkItems)
+	cartRepo := repositories.ConstructCartDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]), maxCartItems)
+	tagRepo := repositories.ConstructTagDBRepository(
+		db.GetConnection(),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAGS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAG_SYNONYMS"]),
+	)
+
+	//An empty APP_MAX_RECENTLY_VIEWED keeps the repository default
+	maxRecentlyViewed, _ := strconv.ParseInt(cfg.GetAppConfig()["MAX_RECENTLY_VIEWED"], 10, 64)
+	recentlyViewedRepo := repositories.ConstructRecentlyViewedDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_RECENTLY_VIEWED"]), maxRecentlyViewed)
+	recommendationRepo := repositories.ConstructRecommendationDBRepository(
+		db.GetConnection(),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAGS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_RECOMMENDATIONS"]),
+	)
+	idempotencyRepo := repositories.ConstructIdempotencyDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_IDEMPOTENCY"]))
+
+	//Connect to Course Service via GRPC, course names are shown
+	grpcCourseService := grpc_client.Construct(cfg)
+	_, err := grpcCourseService.Dial()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	bookmarkUsecase := usecase.ConstructBookmarkUsecase(bookmarkRepo, tagRepo, grpcCourseService)
+	cartUsecase := usecase.ConstructCartUsecase(cartRepo, tagRepo, grpcCourseService)
+	recentlyViewedUsecase := usecase.ConstructRecentlyViewedUsecase(recentlyViewedRepo, grpcCourseService)
+
+	cli := admin.Admin{
+		BookmarkUsecase: bookmarkUsecase,
+		CartUsecase:     cartUsecase,
+		CourseUsecase:   usecase.ConstructCourseUsecase(bookmarkRepo, cartRepo, notifiers.ConstructLogNotifier()),
+		ExportUsecase:   usecase.ConstructExportUsecase(bookmarkUsecase, cartUsecase, recentlyViewedUsecase, recommendationRepo, idempotencyRepo, grpcCourseService),
+		Reindex:         migrations.Construct(db).MigrateSettings,
+		Output:          os.Stdout,
+		JSON:            *output == "json",
+	}
+
+	err = cli.Run(context.Background(), flag.Args())
+	if errors.Is(err, admin.ErrUsage) {
+		if err != admin.ErrUsage {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -2,6 +2,7 @@ package grpc_client
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/models"
 	ps "acourse_tag_cart_bookmark_service/pkg/models/proto_schema"
 	"context"
@@ -17,7 +18,7 @@ type GRPCServiceClient struct {
 	Client ps.CoursesServiceClient
 }
 
-func (c *GRPCServiceClient) List(ctx context.Context, coursesID []string) []models.Course {
+func (c *GRPCServiceClient) List(ctx context.Context, coursesID []string) ([]models.Course, error) {
 
 	//cID := ps.CoursesID{CoursesID: []string{"6300988647b1637e7974b3d9", "6300988647b1637e7974b3d6"}}
 	cID := ps.CoursesID{CoursesID: coursesID}
@@ -25,7 +26,7 @@ func (c *GRPCServiceClient) List(ctx context.Context, coursesID []string) []mode
 	courses, err := c.Client.List(ctx, &cID)
 	if err != nil {
 		log.Println("gRPC Client: CourseService: List Error >>", err)
-		log.Fatal(err)
+		return nil, domain_errors.Unavailable("course service is unavailable", err)
 	}
 
 	coursesResult := make([]models.Course, 0)
@@ -34,7 +35,7 @@ func (c *GRPCServiceClient) List(ctx context.Context, coursesID []string) []mode
 		coursesResult = append(coursesResult, models.Course{ID: models.GenerateObjectIDFromHex(c.Id), Name: c.Name})
 	}
 
-	return coursesResult
+	return coursesResult, nil
 }
 
 func (c *GRPCServiceClient) Dial() (ps.CoursesServiceClient, error) {
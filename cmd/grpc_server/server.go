@@ -0,0 +1,83 @@
+package grpc_server
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/middleware"
+	ps "acourse_tag_cart_bookmark_service/pkg/models/proto_schema"
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log"
+	"net"
+	"strings"
+)
+
+type GRPCServer struct {
+	PORT          string
+	CourseUsecase contracts.CourseUsecase
+	// AllowedIPs are the clients allowed to call the server, like the HTTP internal routes
+	AllowedIPs map[string]bool
+	ps.UnimplementedTagCartBookmarkServiceServer
+}
+
+func (s *GRPCServer) PurgeCourse(ctx context.Context, request *ps.PurgeCourseRequest) (*ps.PurgeCourseResult, error) {
+
+	purge, err := s.CourseUsecase.PurgeCourse(ctx, request.CourseID)
+	if err != nil {
+		log.Println("gRPC Server: PurgeCourse Error >>", err)
+		return nil, status.Error(domain_errors.GRPCCode(err), domain_errors.Message(err))
+	}
+
+	return &ps.PurgeCourseResult{
+		CourseID:          purge.CourseID.Hex(),
+		BookmarksModified: purge.BookmarksModified,
+		CartsModified:     purge.CartsModified,
+		NotificationsSent: purge.NotificationsSent,
+	}, nil
+}
+
+// Serve blocks until the listener fails
+func (s *GRPCServer) Serve() error {
+
+	listener, err := net.Listen("tcp", ":"+s.PORT)
+	if err != nil {
+		return errors.New(fmt.Sprintf("could not listen on %v %v", s.PORT, err))
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(s.whitelist))
+	ps.RegisterTagCartBookmarkServiceServer(server, s)
+
+	log.Println("GRPC Server listening on", listener.Addr())
+
+	return server.Serve(listener)
+}
+
+// whitelist only lets through clients whose IP address is allowed, every RPC of the service is internal
+func (s *GRPCServer) whitelist(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	client, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "client is not allowed to access this resource")
+	}
+
+	ip, _, err := net.SplitHostPort(client.Addr.String())
+	if err != nil || !s.AllowedIPs[ip] {
+		log.Println("gRPC Server: refused", info.FullMethod, "from", client.Addr.String())
+		return nil, status.Error(codes.PermissionDenied, "client is not allowed to access this resource")
+	}
+
+	return handler(ctx, request)
+}
+
+func Construct(config contracts.Config, courseUsecase contracts.CourseUsecase) *GRPCServer {
+	return &GRPCServer{
+		PORT:          config.GetAppConfig()["RPC_PORT"],
+		CourseUsecase: courseUsecase,
+		AllowedIPs:    middleware.AllowedIPs(strings.Split(config.GetAppConfig()["INTERNAL_WHITELIST"], ",")...),
+	}
+}
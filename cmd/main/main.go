@@ -2,18 +2,27 @@ package main
 
 import (
 	"acourse_tag_cart_bookmark_service/cmd/grpc_client"
+	"acourse_tag_cart_bookmark_service/cmd/grpc_server"
 	"acourse_tag_cart_bookmark_service/pkg/config"
 	"acourse_tag_cart_bookmark_service/pkg/database"
 	"acourse_tag_cart_bookmark_service/pkg/database/migrations"
 	"acourse_tag_cart_bookmark_service/pkg/http/controllers"
+	"acourse_tag_cart_bookmark_service/pkg/notifiers"
 	"acourse_tag_cart_bookmark_service/pkg/repositories"
 	"acourse_tag_cart_bookmark_service/pkg/usecase"
 	"github.com/gin-gonic/gin"
+	"log"
+	"strconv"
+	"time"
 )
 
 func main() {
 
 	engine := gin.Default()
+	//No proxy is trusted, ClientIP is the address of the connection and X-Forwarded-For can't be spoofed
+	if err := engine.SetTrustedProxies(nil); err != nil {
+		log.Fatalln("ROUTER:", err)
+	}
 
 	//Create Config Instance
 	cfg := config.Construct(".env")
@@ -24,16 +33,50 @@ func main() {
 
 	//Migrations
 	mg := migrations.Construct(db)
-	mg.MigrateSettings()
+	//A failed index is logged, the service still serves; 'admin reindex' tells once the data is fixed
+	if err := mg.MigrateSettings(); err != nil {
+		log.Println("MIGRATION:", err)
+	}
 
 	//Setup Bookmarks
 	//Repo
+	//An empty APP_MAX_BOOKMARK_ITEMS or APP_MAX_CART_ITEMS keeps the repository default
+	maxBookmarkItems, _ := strconv.ParseInt(cfg.GetAppConfig()["MAX_BOOKMARK_ITEMS"], 10, 64)
+	maxCartItems, _ := strconv.ParseInt(cfg.GetAppConfig()["MAX_CART_ITEMS"], 10, 64)
+
 	bookmarkRepo := repositories.ConstructBookmarkDBRepository(
 		db.GetConnection(),
 		db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"]),
+		maxBookmarkItems,
+	)
+
+	cartRepo := repositories.ConstructCartDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]), maxCartItems)
+
+	tagRepo := repositories.ConstructTagDBRepository(
+		db.GetConnection(),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAGS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAG_SYNONYMS"]),
 	)
 
-	cartRepo := repositories.ConstructCartDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]))
+	statisticRepo := repositories.ConstructStatisticDBRepository(
+		db.GetConnection(),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]),
+	)
+
+	//An empty APP_MAX_RECENTLY_VIEWED keeps the repository default
+	maxRecentlyViewed, _ := strconv.ParseInt(cfg.GetAppConfig()["MAX_RECENTLY_VIEWED"], 10, 64)
+	recentlyViewedRepo := repositories.ConstructRecentlyViewedDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_RECENTLY_VIEWED"]), maxRecentlyViewed)
+
+	recommendationRepo := repositories.ConstructRecommendationDBRepository(
+		db.GetConnection(),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAGS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_RECOMMENDATIONS"]),
+	)
+
+	idempotencyRepo := repositories.ConstructIdempotencyDBRepository(db.GetConnection(), db.GetCollection(cfg.GetDBConfig()["COLLECTION_IDEMPOTENCY"]))
 
 	//Connect to Course Service via GRPC
 	grpcCourseService := grpc_client.Construct(cfg)
@@ -42,11 +85,34 @@ func main() {
 		panic(err)
 	}
 
-	bookmarkUsecase := usecase.ConstructBookmarkUsecase(bookmarkRepo, grpcCourseService)
-	cartUsecase := usecase.ConstructCartUsecase(cartRepo, grpcCourseService)
+	bookmarkUsecase := usecase.ConstructBookmarkUsecase(bookmarkRepo, tagRepo, grpcCourseService)
+	cartUsecase := usecase.ConstructCartUsecase(cartRepo, tagRepo, grpcCourseService)
+	statisticUsecase := usecase.ConstructStatisticUsecase(statisticRepo, grpcCourseService)
+	recentlyViewedUsecase := usecase.ConstructRecentlyViewedUsecase(recentlyViewedRepo, grpcCourseService)
+	//An empty APP_RECOMMENDATION_TTL caches recommendations for an hour
+	recommendationTTL, _ := time.ParseDuration(cfg.GetAppConfig()["RECOMMENDATION_TTL"])
+	recommendationUsecase := usecase.ConstructRecommendationUsecase(recommendationRepo, grpcCourseService, recommendationTTL)
+	tagUsecase := usecase.ConstructTagUsecase(tagRepo, grpcCourseService)
+	exportUsecase := usecase.ConstructExportUsecase(bookmarkUsecase, cartUsecase, recentlyViewedUsecase, recommendationRepo, idempotencyRepo, grpcCourseService)
+	courseUsecase := usecase.ConstructCourseUsecase(bookmarkRepo, cartRepo, notifiers.ConstructLogNotifier())
+
+	//An empty APP_IDEMPOTENCY_TTL replays responses for a day
+	idempotencyTTL, _ := time.ParseDuration(cfg.GetAppConfig()["IDEMPOTENCY_TTL"])
+	idempotencyUsecase := usecase.ConstructIdempotencyUsecase(idempotencyRepo, idempotencyTTL)
+
+	//Serve internal gRPC methods for other services
+	if cfg.GetAppConfig()["RPC_PORT"] != "" {
+		grpcServer := grpc_server.Construct(cfg, courseUsecase)
+		go func() {
+			err := grpcServer.Serve()
+			if err != nil {
+				panic(err)
+			}
+		}()
+	}
 
 	//Setup Delivery/Controller
-	controllers.SetupHandler(engine, &bookmarkUsecase, &cartUsecase)
+	controllers.SetupHandler(engine, cfg, &bookmarkUsecase, &cartUsecase, &statisticUsecase, &courseUsecase, &idempotencyUsecase, &recentlyViewedUsecase, &recommendationUsecase, &tagUsecase, &exportUsecase)
 
 	if port := cfg.GetAppConfig()["PORT"]; port == "" {
 		err := engine.Run(":8080")
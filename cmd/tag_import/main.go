@@ -0,0 +1,77 @@
+package main
+
+import (
+	"acourse_tag_cart_bookmark_service/cmd/grpc_client"
+	"acourse_tag_cart_bookmark_service/pkg/config"
+	"acourse_tag_cart_bookmark_service/pkg/database"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tag courses from a CSV or JSONL file of course_id,tag lines, the report of every line is written to stdout;
+// the exit status is 1 when the import fails and 2 when some lines failed
+func main() {
+
+	file := flag.String("file", "-", "CSV or JSONL of course_id,tag lines, - reads stdin")
+	format := flag.String("format", "", "csv or jsonl, read from the file extension when empty")
+	env := flag.String("env", ".env", "path of the env file")
+	flag.Parse()
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+	}
+
+	var reader io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	//Create Config Instance
+	cfg := config.Construct(*env)
+
+	//Connecting Databases
+	db := database.Construct(cfg)
+	db.Prepare()
+
+	tagRepo := repositories.ConstructTagDBRepository(
+		db.GetConnection(),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAGS"]),
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_TAG_SYNONYMS"]),
+	)
+
+	//Connect to Course Service via GRPC, the course ids are checked there
+	grpcCourseService := grpc_client.Construct(cfg)
+	_, err := grpcCourseService.Dial()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := usecase.ConstructTagUsecase(tagRepo, grpcCourseService).Import(context.Background(), reader, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("%d lines applied, %d failed", report.Applied, report.Failed)
+	if report.Failed > 0 {
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrUsage is returned for a command which doesn't exist or has missing arguments
+var ErrUsage = errors.New("usage")
+
+const Usage = `commands:
+  cart show <user_id>
+  cart clear <user_id>                 remove every course of the cart, courses saved for later are kept
+  cart add <user_id> <course_id> [seats]
+  cart remove <user_id> <course_id>
+  bookmark show <user_id>
+  bookmark export <user_id>            the bookmark as JSON whatever the output
+  export <user_id> [json|zip]          everything stored about the user, redirect the output to a file
+  purge-course <course_id>
+  reindex                              create the indexes and backfill derived fields`
+
+// Admin runs operator commands through the usecases, like the HTTP handlers do
+type Admin struct {
+	BookmarkUsecase contracts.BookmarkUsecase
+	CartUsecase     contracts.CartUsecase
+	CourseUsecase   contracts.CourseUsecase
+	ExportUsecase   contracts.ExportUsecase
+	// Reindex creates the indexes of every collection, it fails when one of them can't be created
+	Reindex func() error
+	Output  io.Writer
+	// JSON writes the results as JSON instead of tables
+	JSON bool
+}
+
+// Run the command of 'args', e.g. ["cart", "show", "user-1"]
+func (a Admin) Run(ctx context.Context, args []string) error {
+
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	switch {
+	case args[0] == "cart" && len(args) >= 3:
+		return a.cart(ctx, args[1], args[2], args[3:])
+	case args[0] == "bookmark" && len(args) == 3 && (args[1] == "show" || args[1] == "export"):
+		return a.bookmark(ctx, args[1], args[2])
+	case args[0] == "export" && (len(args) == 2 || len(args) == 3):
+		format := "json"
+		if len(args) == 3 {
+			format = args[2]
+		}
+		return a.ExportUsecase.Export(ctx, args[1], format, a.Output)
+	case args[0] == "purge-course" && len(args) == 2:
+		purge, err := a.CourseUsecase.PurgeCourse(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		return a.write(purge, [][]string{
+			{"COURSE ID", "BOOKMARKS MODIFIED", "CARTS MODIFIED", "NOTIFICATIONS SENT"},
+			{purge.CourseID.Hex(), strconv.FormatInt(purge.BookmarksModified, 10), strconv.FormatInt(purge.CartsModified, 10), strconv.FormatInt(purge.NotificationsSent, 10)},
+		})
+	case args[0] == "reindex" && len(args) == 1:
+		err := a.Reindex()
+		if err != nil {
+			return err
+		}
+		return a.status(true)
+	default:
+		return ErrUsage
+	}
+}
+
+func (a Admin) cart(ctx context.Context, command string, userID string, args []string) error {
+
+	switch {
+	case command == "show" && len(args) == 0:
+		cart, err := a.CartUsecase.FetchByUserId(ctx, userID, models.Projection{})
+		if err != nil {
+			return err
+		}
+		rows := [][]string{{"COURSE ID", "NAME", "SEATS", "LIST"}}
+		rows = append(rows, courseRows(cart.Courses, "cart")...)
+		rows = append(rows, courseRows(cart.SavedCourses, "saved")...)
+		return a.write(cart, rows)
+
+	case command == "clear" && len(args) == 0:
+		//The cart is cleared at the version it was read, a concurrent change fails the clear instead of being lost
+		cart, err := a.CartUsecase.FetchByUserId(ctx, userID, models.Projection{Fields: []string{"courses", "version"}})
+		if err != nil {
+			return err
+		}
+		if len(cart.Courses) == 0 {
+			return a.status(true)
+		}
+		courses := make([]requests.Course, 0, len(cart.Courses))
+		for _, course := range cart.Courses {
+			courses = append(courses, requests.Course{ID: course.ID.Hex()})
+		}
+		_, err = a.CartUsecase.RevokeCourse(ctx, &requests.RevokeCourseCartRequest{UserID: userID, Courses: courses, Version: cart.Version}, userID)
+		if err != nil {
+			return err
+		}
+		return a.status(true)
+
+	case command == "add" && (len(args) == 1 || len(args) == 2):
+		request := requests.PutCartItemRequest{}
+		if len(args) == 2 {
+			seats, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil || seats < 1 {
+				return fmt.Errorf("seats %q must be a positive number: %w", args[1], ErrUsage)
+			}
+			request.Quantity = seats
+		}
+		_, err := a.CartUsecase.PutCourse(ctx, userID, args[0], &request)
+		if err != nil {
+			return err
+		}
+		return a.status(true)
+
+	case command == "remove" && len(args) == 1:
+		_, err := a.CartUsecase.RevokeCourse(ctx, &requests.RevokeCourseCartRequest{UserID: userID, Courses: []requests.Course{{ID: args[0]}}}, userID)
+		if err != nil {
+			return err
+		}
+		return a.status(true)
+
+	default:
+		return ErrUsage
+	}
+}
+
+func (a Admin) bookmark(ctx context.Context, command string, userID string) error {
+
+	bookmark, err := a.BookmarkUsecase.FetchByUserId(ctx, userID, models.Projection{})
+	if err != nil {
+		return err
+	}
+
+	if command == "export" {
+		return Admin{Output: a.Output, JSON: true}.write(bookmark, nil)
+	}
+
+	rows := [][]string{{"COURSE ID", "NAME", "TAGS", "ADDED AT"}}
+	for _, course := range bookmark.Courses {
+		addedAt := ""
+		if course.AddedAt != nil {
+			addedAt = course.AddedAt.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{course.ID.Hex(), course.Name, strings.Join(course.Tags, ","), addedAt})
+	}
+	return a.write(bookmark, rows)
+}
+
+// write the value as indented JSON, or the rows as a table
+func (a Admin) write(value interface{}, rows [][]string) error {
+
+	if a.JSON {
+		encoder := json.NewEncoder(a.Output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	table := tabwriter.NewWriter(a.Output, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		_, err := fmt.Fprintln(table, strings.Join(row, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+	return table.Flush()
+}
+
+func (a Admin) status(status bool) error {
+	return a.write(responses.Status{Status: status}, [][]string{{"STATUS"}, {strconv.FormatBool(status)}})
+}
+
+func courseRows(courses []models.Course, list string) [][]string {
+	rows := make([][]string, 0, len(courses))
+	for _, course := range courses {
+		rows = append(rows, []string{course.ID.Hex(), course.Name, strconv.FormatInt(course.Seats(), 10), list})
+	}
+	return rows
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// BookmarkListOptions selects a page of the bookmark listing, the cursor takes precedence over the page
+type BookmarkListOptions struct {
+	Page       int64
+	PerPage    int64
+	Cursor     string
+	Projection models.Projection
+	Filter     requests.BookmarkFilterRequest
+}
+
+// ListBookmarks list bookmarks, pass the returned NextCursor as Cursor to read the next page
+func (c *Client) ListBookmarks(ctx context.Context, options BookmarkListOptions) ([]models.Bookmark, responses.Pagination, error) {
+
+	query := projectionQuery(options.Projection)
+	if options.Cursor != "" {
+		query.Set("cursor", options.Cursor)
+	} else if options.Page > 0 {
+		query.Set("page", strconv.FormatInt(options.Page, 10))
+	}
+	if options.PerPage > 0 {
+		query.Set("per_page", strconv.FormatInt(options.PerPage, 10))
+	}
+	encodeQuery(query, options.Filter)
+
+	bookmarks := make([]models.Bookmark, 0)
+	pagination, err := c.do(ctx, http.MethodGet, "/bookmark/", query, nil, &bookmarks)
+	if err != nil {
+		return nil, responses.Pagination{}, err
+	}
+	return bookmarks, paginationOf(pagination), nil
+}
+
+func (c *Client) GetBookmark(ctx context.Context, id string, projection models.Projection) (models.Bookmark, error) {
+	var bookmark models.Bookmark
+	_, err := c.do(ctx, http.MethodGet, pathOf("bookmark", id), projectionQuery(projection), nil, &bookmark)
+	return bookmark, err
+}
+
+func (c *Client) GetUserBookmark(ctx context.Context, userID string, projection models.Projection) (models.Bookmark, error) {
+	var bookmark models.Bookmark
+	_, err := c.do(ctx, http.MethodGet, pathOf("bookmark", "u", userID), projectionQuery(projection), nil, &bookmark)
+	return bookmark, err
+}
+
+func (c *Client) ListBookmarkCourses(ctx context.Context, userID string, request requests.CourseListRequest) ([]models.Course, responses.Pagination, error) {
+	courses := make([]models.Course, 0)
+	pagination, err := c.do(ctx, http.MethodGet, pathOf("bookmark", "u", userID, "courses"), encodeQuery(url.Values{}, request), nil, &courses)
+	if err != nil {
+		return nil, responses.Pagination{}, err
+	}
+	return courses, paginationOf(pagination), nil
+}
+
+// AddBookmarkCourses add courses to the user's bookmark, the bookmark is created on the first add
+func (c *Client) AddBookmarkCourses(ctx context.Context, userID string, coursesID []string) (responses.Status, error) {
+	var status responses.Status
+	body := requests.AddCourseBookmarkRequest{UserID: userID, Courses: courseRequests(coursesID)}
+	_, err := c.do(ctx, http.MethodPatch, pathOf("bookmark", "course", "add", userID), nil, body, &status)
+	return status, err
+}
+
+func (c *Client) RemoveBookmarkCourses(ctx context.Context, userID string, coursesID []string) (responses.Status, error) {
+	var status responses.Status
+	body := requests.DeleteAttachedCourseRequest{UserID: userID, Courses: courseRequests(coursesID)}
+	_, err := c.do(ctx, http.MethodDelete, pathOf("bookmark", "course", "delete", userID), nil, body, &status)
+	return status, err
+}
+
+func projectionQuery(projection models.Projection) url.Values {
+	query := url.Values{}
+	if len(projection.Fields) > 0 {
+		query.Set("fields", strings.Join(projection.Fields, ","))
+	}
+	if len(projection.Exclude) > 0 {
+		query.Set("exclude", strings.Join(projection.Exclude, ","))
+	}
+	return query
+}
+
+func courseRequests(coursesID []string) []requests.Course {
+	courses := make([]requests.Course, 0, len(coursesID))
+	for _, id := range coursesID {
+		courses = append(courses, requests.Course{ID: id})
+	}
+	return courses
+}
+
+func paginationOf(pagination *responses.Pagination) responses.Pagination {
+	if pagination == nil {
+		return responses.Pagination{}
+	}
+	return *pagination
+}
+
+// CreateUserBookmark create the user's bookmark through the v2 routes, it fails with a conflict when the user has one
+func (c *Client) CreateUserBookmark(ctx context.Context, userID string, coursesID []string) (models.Bookmark, error) {
+	var bookmark models.Bookmark
+	body := requests.CreateUserBookmarkRequest{Courses: courseRequests(coursesID)}
+	_, err := c.do(ctx, http.MethodPost, pathOf("v2", "users", userID, "bookmarks"), nil, body, &bookmark)
+	return bookmark, err
+}
+
+// PutBookmarkItem bookmark a course through the v2 routes, the call is idempotent and retried
+func (c *Client) PutBookmarkItem(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodPut, pathOf("v2", "users", userID, "bookmarks", "items", courseID), nil, nil, &status)
+	return status, err
+}
+
+func (c *Client) DeleteBookmarkItem(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodDelete, pathOf("v2", "users", userID, "bookmarks", "items", courseID), nil, nil, &status)
+	return status, err
+}
+
+// GetUserBookmarkByTag fetch the user's bookmark with the courses carrying a personal tag only
+func (c *Client) GetUserBookmarkByTag(ctx context.Context, userID string, tag string, projection models.Projection) (models.Bookmark, error) {
+	var bookmark models.Bookmark
+	query := projectionQuery(projection)
+	query.Set("tag", tag)
+	_, err := c.do(ctx, http.MethodGet, pathOf("bookmark", "u", userID), query, nil, &bookmark)
+	return bookmark, err
+}
+
+// SetBookmarkItemTags replace the personal tags of a bookmarked course, the call is idempotent and retried
+func (c *Client) SetBookmarkItemTags(ctx context.Context, userID string, courseID string, tags []string) (responses.Status, error) {
+	var status responses.Status
+	body := requests.CourseTagsRequest{Tags: tags}
+	_, err := c.do(ctx, http.MethodPut, pathOf("v2", "users", userID, "bookmarks", "items", courseID, "tags"), nil, body, &status)
+	return status, err
+}
+
+func (c *Client) ClearBookmarkItemTags(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodDelete, pathOf("v2", "users", userID, "bookmarks", "items", courseID, "tags"), nil, nil, &status)
+	return status, err
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+func (c *Client) GetCart(ctx context.Context, id string, projection models.Projection) (models.Cart, error) {
+	var cart models.Cart
+	_, err := c.do(ctx, http.MethodGet, pathOf("cart", id), projectionQuery(projection), nil, &cart)
+	return cart, err
+}
+
+func (c *Client) GetUserCart(ctx context.Context, userID string, projection models.Projection) (models.Cart, error) {
+	var cart models.Cart
+	_, err := c.do(ctx, http.MethodGet, pathOf("cart", "u", userID), projectionQuery(projection), nil, &cart)
+	return cart, err
+}
+
+func (c *Client) ListCartCourses(ctx context.Context, userID string, request requests.CourseListRequest) ([]models.Course, responses.Pagination, error) {
+	courses := make([]models.Course, 0)
+	pagination, err := c.do(ctx, http.MethodGet, pathOf("cart", "u", userID, "courses"), encodeQuery(url.Values{}, request), nil, &courses)
+	if err != nil {
+		return nil, responses.Pagination{}, err
+	}
+	return courses, paginationOf(pagination), nil
+}
+
+// AddCartCourses add courses to the user's cart, the cart is created on the first add
+func (c *Client) AddCartCourses(ctx context.Context, userID string, coursesID []string) (responses.Status, error) {
+	var status responses.Status
+	items := make([]requests.CartItem, 0, len(coursesID))
+	for _, id := range coursesID {
+		items = append(items, requests.CartItem{ID: id})
+	}
+	body := requests.AddCourseCartRequest{UserID: userID, Courses: items}
+	_, err := c.do(ctx, http.MethodPatch, pathOf("cart", "course", "add", userID), nil, body, &status)
+	return status, err
+}
+
+func (c *Client) RemoveCartCourses(ctx context.Context, userID string, coursesID []string) (responses.Status, error) {
+	var status responses.Status
+	body := requests.RevokeCourseCartRequest{UserID: userID, Courses: courseRequests(coursesID)}
+	_, err := c.do(ctx, http.MethodDelete, pathOf("cart", "course", "revoke", userID), nil, body, &status)
+	return status, err
+}
+
+// PutCartItem add a course to the user's cart through the v2 routes, the call is idempotent and retried
+func (c *Client) PutCartItem(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodPut, pathOf("v2", "users", userID, "cart", "items", courseID), nil, nil, &status)
+	return status, err
+}
+
+// PutCartItemSeats set the seats of a course in the user's cart, gifted seats need one recipient each
+func (c *Client) PutCartItemSeats(ctx context.Context, userID string, courseID string, seats requests.PutCartItemRequest) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodPut, pathOf("v2", "users", userID, "cart", "items", courseID), nil, seats, &status)
+	return status, err
+}
+
+func (c *Client) GetCartSummary(ctx context.Context, userID string) (models.CartSummary, error) {
+	var summary models.CartSummary
+	_, err := c.do(ctx, http.MethodGet, pathOf("v2", "users", userID, "cart", "summary"), nil, nil, &summary)
+	return summary, err
+}
+
+func (c *Client) DeleteCartItem(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodDelete, pathOf("v2", "users", userID, "cart", "items", courseID), nil, nil, &status)
+	return status, err
+}
+
+// SaveCartItemForLater move a course of the user's cart to the courses saved for later, the call is idempotent and retried
+func (c *Client) SaveCartItemForLater(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodPut, pathOf("v2", "users", userID, "cart", "saved", courseID), nil, nil, &status)
+	return status, err
+}
+
+// RestoreSavedCartItem move a course saved for later back to the user's cart
+func (c *Client) RestoreSavedCartItem(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodPost, pathOf("v2", "users", userID, "cart", "saved", courseID, "restore"), nil, nil, &status)
+	return status, err
+}
+
+func (c *Client) DeleteSavedCartItem(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodDelete, pathOf("v2", "users", userID, "cart", "saved", courseID), nil, nil, &status)
+	return status, err
+}
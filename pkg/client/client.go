@@ -0,0 +1,263 @@
+package client
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries = 2
+	defaultBackoff = 200 * time.Millisecond
+)
+
+// Client calls the tag cart bookmark service over HTTP,
+// idempotent calls are retried on network errors and on 502, 503 and 504,
+// mutations are only retried when they carry an Idempotency-Key
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	tokenSource func(ctx context.Context) (string, error)
+	retries     int
+	backoff     time.Duration
+
+	idempotencyKeys bool
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sends a static bearer token with every request
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.tokenSource = func(ctx context.Context) (string, error) {
+			return token, nil
+		}
+	}
+}
+
+// WithTokenSource asks 'source' for a bearer token before every attempt, e.g. to refresh expired tokens
+func WithTokenSource(source func(ctx context.Context) (string, error)) Option {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
+// WithRetries sets how many times an idempotent call is retried, the wait doubles after each attempt
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+type ifMatchKey struct{}
+
+// IfMatch makes the mutations called with the returned context conditional on the 'version' of the bookmark or cart,
+// they fail with domain_errors.ErrPreconditionFailed when the document has changed since
+func IfMatch(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, version)
+}
+
+// WithIdempotencyKeys sends a generated Idempotency-Key with every mutation,
+// the service replays the first response for a retried key so mutations are retried as well
+func WithIdempotencyKeys() Option {
+	return func(c *Client) {
+		c.idempotencyKeys = true
+	}
+}
+
+func Construct(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// do send a request and decode the 'data' of the envelope into 'out',
+// the pagination of the envelope is returned when there is one
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body interface{}, out interface{}) (*responses.Pagination, error) {
+
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//The same key is sent with every attempt
+	var idempotencyKey string
+	if c.idempotencyKeys && !idempotent(method) {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	attempts := 1
+	if idempotent(method) || idempotencyKey != "" {
+		attempts += c.retries
+	}
+
+	var lastErr error
+	wait := c.backoff
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+
+		pagination, retry, err := c.attempt(ctx, method, endpoint, payload, idempotencyKey, out)
+		if err == nil {
+			return pagination, nil
+		}
+		lastErr = err
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, method string, endpoint string, payload []byte, idempotencyKey string, out interface{}) (pagination *responses.Pagination, retry bool, err error) {
+
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, false, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		request.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	if version, ok := ctx.Value(ifMatchKey{}).(int64); ok && version > 0 {
+		request.Header.Set("If-Match", strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+	if c.tokenSource != nil {
+		token, err := c.tokenSource(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, true, err
+	}
+	defer response.Body.Close()
+
+	envelope := responses.Envelope{Data: out}
+	decodeErr := json.NewDecoder(response.Body).Decode(&envelope)
+
+	if response.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: response.StatusCode, RequestID: response.Header.Get("X-Request-ID")}
+		if decodeErr == nil && envelope.Error != nil {
+			apiErr.Code = envelope.Error.Code
+			apiErr.Message = envelope.Error.Message
+			apiErr.Details = envelope.Error.Details
+			apiErr.RequestID = envelope.Meta.RequestID
+		} else {
+			apiErr.Message = http.StatusText(response.StatusCode)
+		}
+		return nil, retryable(response.StatusCode), apiErr
+	}
+
+	if decodeErr != nil {
+		return nil, false, fmt.Errorf("decode response: %w", decodeErr)
+	}
+
+	return envelope.Meta.Pagination, false, nil
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func newIdempotencyKey() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func retryable(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// encodeQuery encode the fields of a struct bound with 'form' tags, zero values are left out
+func encodeQuery(query url.Values, v interface{}) url.Values {
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("form"), ",")[0]
+		field := value.Field(i)
+		if name == "" || name == "-" || field.IsZero() {
+			continue
+		}
+
+		switch f := field.Interface().(type) {
+		case string:
+			query.Set(name, f)
+		case int64:
+			query.Set(name, strconv.FormatInt(f, 10))
+		case *time.Time:
+			query.Set(name, f.Format(time.RFC3339))
+		}
+	}
+	return query
+}
+
+// pathOf join escaped segments, e.g. pathOf("cart", "u", userID)
+func pathOf(segments ...string) string {
+	escaped := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+	return "/" + strings.Join(escaped, "/")
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"fmt"
+)
+
+// Error is an error envelope answered by the service,
+// it matches the domain error kinds with errors.Is, e.g. errors.Is(err, domain_errors.ErrNotFound)
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    interface{}
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("tag cart bookmark service: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch e.Code {
+	case "NOT_FOUND":
+		return target == domain_errors.ErrNotFound
+	case "CONFLICT":
+		return target == domain_errors.ErrConflict
+	case "INVALID_ARGUMENT":
+		return target == domain_errors.ErrInvalidArgument
+	case "UNAVAILABLE":
+		return target == domain_errors.ErrUnavailable
+	case "PRECONDITION_FAILED":
+		return target == domain_errors.ErrPreconditionFailed
+	case "QUOTA_EXCEEDED":
+		return target == domain_errors.ErrQuotaExceeded
+	default:
+		return false
+	}
+}
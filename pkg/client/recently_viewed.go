@@ -0,0 +1,23 @@
+package client
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// RecordView move a course to the front of the user's recently viewed courses, the call is idempotent and retried
+func (c *Client) RecordView(ctx context.Context, userID string, courseID string) (responses.Status, error) {
+	var status responses.Status
+	_, err := c.do(ctx, http.MethodPut, pathOf("v2", "users", userID, "recently-viewed", courseID), nil, nil, &status)
+	return status, err
+}
+
+func (c *Client) GetRecentlyViewed(ctx context.Context, userID string, request requests.RecentlyViewedRequest) (models.RecentlyViewed, error) {
+	var recentlyViewed models.RecentlyViewed
+	_, err := c.do(ctx, http.MethodGet, pathOf("v2", "users", userID, "recently-viewed"), encodeQuery(url.Values{}, request), nil, &recentlyViewed)
+	return recentlyViewed, err
+}
@@ -0,0 +1,16 @@
+package client
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Recommend list the courses recommended to the user, best first
+func (c *Client) Recommend(ctx context.Context, userID string, request requests.RecommendationRequest) ([]models.RecommendedCourse, error) {
+	courses := make([]models.RecommendedCourse, 0)
+	_, err := c.do(ctx, http.MethodGet, pathOf("recommendations", "u", userID), encodeQuery(url.Values{}, request), nil, &courses)
+	return courses, err
+}
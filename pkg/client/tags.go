@@ -0,0 +1,23 @@
+package client
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// GetTag fetch a tag by its name or one of its synonyms
+func (c *Client) GetTag(ctx context.Context, name string) (models.Tag, error) {
+	var tag models.Tag
+	_, err := c.do(ctx, http.MethodGet, pathOf("tags", name), nil, nil, &tag)
+	return tag, err
+}
+
+// SearchTags suggest the tags starting with 'request.Q', the tags carrying the most courses first
+func (c *Client) SearchTags(ctx context.Context, request requests.TagSearchRequest) ([]models.TagSuggestion, error) {
+	tags := make([]models.TagSuggestion, 0)
+	_, err := c.do(ctx, http.MethodGet, pathOf("tag", "search"), encodeQuery(url.Values{}, request), nil, &tags)
+	return tags, err
+}
@@ -25,6 +25,13 @@ func Construct(envpath string) contracts.Config {
 	c.App["PORT"] = os.Getenv("APP_PORT")
 	c.App["RPC_TARGET_HOST"] = os.Getenv("RPC_TARGET_HOST")
 	c.App["RPC_TARGET_PORT"] = os.Getenv("RPC_TARGET_PORT")
+	c.App["RPC_PORT"] = os.Getenv("APP_RPC_PORT")
+	c.App["INTERNAL_WHITELIST"] = os.Getenv("APP_INTERNAL_WHITELIST")
+	c.App["IDEMPOTENCY_TTL"] = os.Getenv("APP_IDEMPOTENCY_TTL")
+	c.App["MAX_BOOKMARK_ITEMS"] = os.Getenv("APP_MAX_BOOKMARK_ITEMS")
+	c.App["MAX_CART_ITEMS"] = os.Getenv("APP_MAX_CART_ITEMS")
+	c.App["MAX_RECENTLY_VIEWED"] = os.Getenv("APP_MAX_RECENTLY_VIEWED")
+	c.App["RECOMMENDATION_TTL"] = os.Getenv("APP_RECOMMENDATION_TTL")
 
 	c.Database = map[string]string{}
 	c.Database["USERNAME"] = os.Getenv("DB_USERNAME")
@@ -34,11 +41,24 @@ func Construct(envpath string) contracts.Config {
 	c.Database["NAME"] = os.Getenv("DB_NAME")
 	c.Database["COLLECTION_BOOKMARKS"] = os.Getenv("DB_COLLECTION_BOOKMARKS")
 	c.Database["COLLECTION_TAGS"] = os.Getenv("DB_COLLECTION_TAGS")
+	c.Database["COLLECTION_TAG_SYNONYMS"] = getenv("DB_COLLECTION_TAG_SYNONYMS", "tag_synonyms")
 	c.Database["COLLECTION_CARTS"] = os.Getenv("DB_COLLECTION_CARTS")
+	c.Database["COLLECTION_IDEMPOTENCY"] = getenv("DB_COLLECTION_IDEMPOTENCY", "idempotency_records")
+	c.Database["COLLECTION_RECENTLY_VIEWED"] = getenv("DB_COLLECTION_RECENTLY_VIEWED", "recently_viewed")
+	c.Database["COLLECTION_RECOMMENDATIONS"] = getenv("DB_COLLECTION_RECOMMENDATIONS", "recommendations")
 
 	return &c
 }
 
+// getenv returns 'fallback' when the variable is unset or empty,
+// so a collection added after a deployment has a name without its .env being updated
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
 func (c *Config) GetDBConfig() map[string]string {
 	return c.Database
 }
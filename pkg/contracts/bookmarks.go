@@ -9,35 +9,64 @@ import (
 
 type BookmarksDBRepository interface {
 	// Fetch List all data from database;
-	// 'exclude' param specify which model fields you want to skip/unselect;
-	// 'limit' and 'skip param are used to perform some kind of pagination
-	Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, err error)
+	// 'projection' param specify which model fields you want to select or skip/unselect;
+	// 'filter' narrows down the listed bookmarks;
+	// 'pagination' selects a page by cursor or page number, its total, next cursor and has next are filled in
+	Fetch(ctx context.Context, projection models.Projection, filter models.BookmarkFilter, pagination *models.Pagination) (bookmarks []models.Bookmark, err error)
 	// FetchById fetch data by id;
-	// 'exclude' param specify which model fields you want to skip/unselect;
-	FetchById(ctx context.Context, id string, exclude []string) (bookmark models.Bookmark, err error)
-	FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, err error)
+	// 'projection' param specify which model fields you want to select or skip/unselect;
+	FetchById(ctx context.Context, id string, projection models.Projection) (bookmark models.Bookmark, err error)
+	FetchByUserId(ctx context.Context, userID string, projection models.Projection) (bookmark models.Bookmark, err error)
+	// FetchByUserTag fetch the user's bookmark with the courses carrying the personal 'tag' only
+	FetchByUserTag(ctx context.Context, userID string, tag string, projection models.Projection) (bookmark models.Bookmark, err error)
+	// FetchCourses select a page of the courses embedded in the user's bookmark, ordered by added date;
+	// 'coursesID' restricts the courses to the given ids when it isn't nil, a zero 'limit' selects every course
+	FetchCourses(ctx context.Context, userID string, coursesID []primitive.ObjectID, descending bool, limit int64, skip int64) (courses []models.Course, total int64, err error)
 	Create(ctx context.Context, bookmark *models.Bookmark) (bookmarkID primitive.ObjectID, err error)
+	// Update replace the bookmark when it is still at 'bookmark.Version', it fails with a precondition failure otherwise
 	Update(ctx context.Context, bookmark *models.Bookmark, bookmarkID string) (status bool, err error)
-	AddCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error)
+	// AddCourse append courses to the user's document, the document is created by the first add;
+	// a non zero 'version' requires the document to be at that version, it fails with a precondition failure otherwise.
+	// Returns the version the write left the document at, courses which are all bookmarked already are a conflict at the current version
+	AddCourse(ctx context.Context, userID string, coursesID []string, version int64) (newVersion int64, err error)
 	Delete(ctx context.Context, bookmarkID string) (status bool, err error)
-	RevokeCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error)
+	// RevokeCourse remove courses from the user's document, 'version' is checked and returned like in AddCourse
+	RevokeCourse(ctx context.Context, userID string, coursesID []string, version int64) (newVersion int64, err error)
+	// SetCourseTags replace the personal tags of a bookmarked course, no tag clears them;
+	// 'version' is checked and returned like in AddCourse, a course which isn't bookmarked is not found
+	SetCourseTags(ctx context.Context, userID string, courseID primitive.ObjectID, tags []string, version int64) (newVersion int64, err error)
+	// PurgeCourse pull a course from every bookmark;
+	// returns the owners of the affected bookmarks and how many documents were modified
+	PurgeCourse(ctx context.Context, courseID primitive.ObjectID) (usersID []string, modified int64, err error)
 	GenerateModelID() primitive.ObjectID
 	GenerateObjectIDFromString(id string) primitive.ObjectID
 }
 
 type BookmarkUsecase interface {
 	// Fetch List all data from database;
-	// 'exclude' param specify which model fields you want to skip/unselect;
-	// 'limit' and 'skip param are used to perform some kind of pagination
-	Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, err error)
+	// 'projection' param specify which model fields you want to select or skip/unselect;
+	// 'filter' narrows down the listed bookmarks;
+	// 'pagination' selects a page by cursor or page number, its total, next cursor and has next are filled in
+	Fetch(ctx context.Context, projection models.Projection, filter *requests.BookmarkFilterRequest, pagination *models.Pagination) (bookmarks []models.Bookmark, err error)
 
 	// FetchById fetch data by id;
-	// 'exclude' param specify which model fields you want to skip/unselect;
-	FetchById(ctx context.Context, id string, exclude []string) (bookmark models.Bookmark, err error)
+	// 'projection' param specify which model fields you want to select or skip/unselect,
+	// courses are only hydrated from the Course service when the projection selects them;
+	FetchById(ctx context.Context, id string, projection models.Projection) (bookmark models.Bookmark, err error)
 
-	FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, err error)
+	FetchByUserId(ctx context.Context, userID string, projection models.Projection) (bookmark models.Bookmark, err error)
+	// FetchByUserTag is FetchByUserId with the courses carrying the personal 'tag' only
+	FetchByUserTag(ctx context.Context, userID string, tag string, projection models.Projection) (bookmark models.Bookmark, err error)
+	// FetchCourses list a page of the courses in the user's bookmark, only that page is hydrated unless sorting or filtering by name
+	FetchCourses(ctx context.Context, userID string, request *requests.CourseListRequest) (courses []models.Course, pagination models.Pagination, err error)
 	Create(ctx context.Context, request *requests.CreateBookmarkRequest) (bookmark models.Bookmark, err error)
-	AddCourse(ctx context.Context, request *requests.AddCourseBookmarkRequest, userID string) (status bool, err error)
-	RevokeCourse(ctx context.Context, request *requests.DeleteAttachedCourseRequest, userID string) (status bool, err error)
+	// AddCourse add courses to the user's bookmark, returns the version of the bookmark after the add;
+	// courses which are all bookmarked already are a conflict which still answers the current version
+	AddCourse(ctx context.Context, request *requests.AddCourseBookmarkRequest, userID string) (version int64, err error)
+	// RevokeCourse remove courses from the user's bookmark, returns the version of the bookmark after the removal
+	RevokeCourse(ctx context.Context, request *requests.DeleteAttachedCourseRequest, userID string) (version int64, err error)
+	// SetCourseTags replace the personal tags of a bookmarked course, tags are lower cased and kept once;
+	// returns the version of the bookmark after the change
+	SetCourseTags(ctx context.Context, userID string, courseID string, request *requests.CourseTagsRequest) (version int64, err error)
 	Delete(ctx context.Context, bookmarkID string) (status bool, err error)
 }
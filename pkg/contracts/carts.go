@@ -9,18 +9,54 @@ import (
 
 type CartDBRepository interface {
 	// FetchById fetch data by id;
-	// 'exclude' param specify which model fields you want to skip/unselect;
-	FetchById(ctx context.Context, id string, exclude []string) (cart models.Cart, err error)
-	FetchByUserId(ctx context.Context, userID string, exclude []string) (cart models.Cart, err error)
+	// 'projection' param specify which model fields you want to select or skip/unselect;
+	FetchById(ctx context.Context, id string, projection models.Projection) (cart models.Cart, err error)
+	FetchByUserId(ctx context.Context, userID string, projection models.Projection) (cart models.Cart, err error)
+	// FetchCourses select a page of the courses embedded in the user's cart, ordered by added date;
+	// 'coursesID' restricts the courses to the given ids when it isn't nil, a zero 'limit' selects every course
+	FetchCourses(ctx context.Context, userID string, coursesID []primitive.ObjectID, descending bool, limit int64, skip int64) (courses []models.Course, total int64, err error)
 	Create(ctx context.Context, cart *models.Cart) (cartId primitive.ObjectID, err error)
-	AddCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error)
-	RevokeCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error)
+	// AddCourse append courses to the user's cart with their seats, courses already in the cart are skipped,
+	// the cart is created by the first add;
+	// a non zero 'version' requires the cart to be at that version, it fails with a precondition failure otherwise;
+	// returns the version the write left the cart at
+	AddCourse(ctx context.Context, userID string, courses []models.Course, version int64) (newVersion int64, err error)
+	// PutCourse set the seats of a course in the user's cart, the course is appended when it isn't in the cart yet;
+	// 'version' is checked and returned like in AddCourse
+	PutCourse(ctx context.Context, userID string, course models.Course, version int64) (newVersion int64, err error)
+	// RevokeCourse remove courses from the user's document, 'version' is checked and returned like in AddCourse
+	RevokeCourse(ctx context.Context, userID string, coursesID []string, version int64) (newVersion int64, err error)
+	// MoveCourse move a course of the user's cart to the courses saved for later when 'saved' is true, back to the cart otherwise;
+	// the course keeps its seats, moving it again succeeds, 'version' is checked and returned like in AddCourse
+	MoveCourse(ctx context.Context, userID string, courseID primitive.ObjectID, saved bool, version int64) (newVersion int64, err error)
+	// RevokeSavedCourse remove courses from the courses saved for later, 'version' is checked and returned like in AddCourse
+	RevokeSavedCourse(ctx context.Context, userID string, coursesID []primitive.ObjectID, version int64) (newVersion int64, err error)
+	// PurgeCourse pull a course from every cart;
+	// returns the owners of the affected carts and how many documents were modified
+	PurgeCourse(ctx context.Context, courseID primitive.ObjectID) (usersID []string, modified int64, err error)
 	Delete(ctx context.Context, cartID string) (status bool, err error)
 }
 
 type CartUsecase interface {
-	FetchById(ctx context.Context, id string, exclude []string) (cart models.Cart, err error)
-	FetchByUserId(ctx context.Context, userID string, exclude []string) (cart models.Cart, err error)
-	AddCourse(ctx context.Context, request *requests.AddCourseCartRequest, userID string) (status bool, err error)
-	RevokeCourse(ctx context.Context, request *requests.RevokeCourseCartRequest, userID string) (status bool, err error)
+	// FetchById fetch data by id;
+	// 'projection' param specify which model fields you want to select or skip/unselect,
+	// courses are only hydrated from the Course service when the projection selects them;
+	FetchById(ctx context.Context, id string, projection models.Projection) (cart models.Cart, err error)
+	FetchByUserId(ctx context.Context, userID string, projection models.Projection) (cart models.Cart, err error)
+	// FetchCourses list a page of the courses in the user's cart, only that page is hydrated unless sorting or filtering by name
+	FetchCourses(ctx context.Context, userID string, request *requests.CourseListRequest) (courses []models.Course, pagination models.Pagination, err error)
+	// AddCourse add courses to the user's cart, a gift needs one recipient per seat;
+	// like every change of the cart it returns the version of the cart after the change
+	AddCourse(ctx context.Context, request *requests.AddCourseCartRequest, userID string) (version int64, err error)
+	// PutCourse set the seats of a course in the user's cart, adding it when it isn't in the cart yet
+	PutCourse(ctx context.Context, userID string, courseID string, request *requests.PutCartItemRequest) (version int64, err error)
+	RevokeCourse(ctx context.Context, request *requests.RevokeCourseCartRequest, userID string) (version int64, err error)
+	// Summary count the items and seats in the user's cart, courses saved for later aren't counted as items
+	Summary(ctx context.Context, userID string) (summary models.CartSummary, err error)
+	// SaveForLater move a course of the user's cart to the courses saved for later
+	SaveForLater(ctx context.Context, userID string, courseID string, version int64) (newVersion int64, err error)
+	// MoveToCart move a course saved for later back to the user's cart
+	MoveToCart(ctx context.Context, userID string, courseID string, version int64) (newVersion int64, err error)
+	// RemoveSaved remove a course from the courses saved for later
+	RemoveSaved(ctx context.Context, userID string, courseID string, version int64) (newVersion int64, err error)
 }
@@ -0,0 +1,17 @@
+package contracts
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+)
+
+type CourseUsecase interface {
+	// PurgeCourse remove a course deleted by the Course service from every bookmark and cart
+	PurgeCourse(ctx context.Context, courseID string) (purge models.CoursePurge, err error)
+}
+
+// Notifier tells users that something they saved has changed
+type Notifier interface {
+	// NotifyCoursePurged 'source' is either bookmarks or carts
+	NotifyCoursePurged(ctx context.Context, userID string, courseID string, source string) error
+}
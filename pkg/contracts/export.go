@@ -0,0 +1,12 @@
+package contracts
+
+import (
+	"context"
+	"io"
+)
+
+type ExportUsecase interface {
+	// Export write everything stored about the user to 'w', as a 'json' document or a 'zip' of one json file per section;
+	// sections are fetched and written one at a time, nothing is written when the first section fails
+	Export(ctx context.Context, userID string, format string, w io.Writer) error
+}
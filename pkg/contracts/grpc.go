@@ -8,7 +8,7 @@ import (
 
 type GRPCClient interface {
 	Dial() (ps.CoursesServiceClient, error)
-	List(ctx context.Context, coursesID []string) []models.Course
+	List(ctx context.Context, coursesID []string) ([]models.Course, error)
 }
 
 type GRPCCourseService interface {
@@ -0,0 +1,28 @@
+package contracts
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+)
+
+type IdempotencyDBRepository interface {
+	// Create reserve a key for a user, it fails with a conflict when the key is already reserved
+	Create(ctx context.Context, record *models.IdempotencyRecord) error
+	FetchByKey(ctx context.Context, userID string, key string) (record models.IdempotencyRecord, err error)
+	// Complete store the response of the request which reserved the key
+	Complete(ctx context.Context, userID string, key string, response models.IdempotentResponse) error
+	Delete(ctx context.Context, userID string, key string) error
+	// FetchByUserId list the records of the user which haven't expired yet, oldest first
+	FetchByUserId(ctx context.Context, userID string) (records []models.IdempotencyRecord, err error)
+}
+
+type IdempotencyUsecase interface {
+	// Begin reserve 'key' for the request identified by 'fingerprint';
+	// returns the stored record when the key was already used by the same request and its response is known,
+	// a conflict when the key was used by a different request or the first request is still in progress
+	Begin(ctx context.Context, userID string, key string, fingerprint string) (replay *models.IdempotencyRecord, err error)
+	// Complete store the response which is replayed for retries
+	Complete(ctx context.Context, userID string, key string, response models.IdempotentResponse) error
+	// Abort release the key so a retry is handled again, e.g. after a server error
+	Abort(ctx context.Context, userID string, key string) error
+}
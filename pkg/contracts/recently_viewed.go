@@ -0,0 +1,24 @@
+package contracts
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type RecentlyViewedDBRepository interface {
+	// Record move the course to the front of the user's recently viewed courses, the list is created by the first view;
+	// the oldest courses are dropped past the repository's length
+	Record(ctx context.Context, userID string, courseID primitive.ObjectID, viewedAt time.Time) error
+	// FetchByUserId select the 'limit' most recent courses, a zero 'limit' selects every course
+	FetchByUserId(ctx context.Context, userID string, limit int64) (recentlyViewed models.RecentlyViewed, err error)
+}
+
+type RecentlyViewedUsecase interface {
+	Record(ctx context.Context, userID string, courseID string) error
+	// Fetch list the courses the user viewed last with their names from the Course service,
+	// a user who viewed nothing has an empty list
+	Fetch(ctx context.Context, userID string, request *requests.RecentlyViewedRequest) (recentlyViewed models.RecentlyViewed, err error)
+}
@@ -0,0 +1,26 @@
+package contracts
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RecommendationDBRepository interface {
+	// Compute score the courses bookmarked by other users together with the user's bookmarked courses;
+	// courses the user already bookmarked, carted or saved for later are left out, a user without bookmark has none
+	Compute(ctx context.Context, userID string, limit int64) (courses []models.RecommendedCourse, err error)
+	// OwnedCourses list the ids of the courses the user bookmarked, carted or saved for later
+	OwnedCourses(ctx context.Context, userID string) ([]primitive.ObjectID, error)
+	// FetchCached fetch the recommendation of the user which hasn't expired, it is not found otherwise
+	FetchCached(ctx context.Context, userID string) (recommendation models.Recommendation, err error)
+	// Cache replace the cached recommendation of the user
+	Cache(ctx context.Context, recommendation *models.Recommendation) error
+}
+
+type RecommendationUsecase interface {
+	// Recommend list the recommended courses of the user, best first, with their names from the Course service;
+	// results are cached per user, the courses the user bookmarked, carted or saved since are left out of the cached courses
+	Recommend(ctx context.Context, userID string, request *requests.RecommendationRequest) (courses []models.RecommendedCourse, err error)
+}
@@ -0,0 +1,21 @@
+package contracts
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"time"
+)
+
+type StatisticDBRepository interface {
+	// CountByCourses count how many bookmarks and carts contain each of the given courses
+	CountByCourses(ctx context.Context, coursesID []string) (statistics []models.CourseStatistic, err error)
+	// TopCourses rank courses by how many documents of 'source' (bookmarks or carts) contain them;
+	// 'since' and 'until' bound the date a course was added, nil leaves that side of the window open
+	TopCourses(ctx context.Context, source string, limit int64, since *time.Time, until *time.Time) (statistics []models.CourseStatistic, err error)
+}
+
+type StatisticUsecase interface {
+	CountByCourses(ctx context.Context, coursesID []string) (statistics []models.CourseStatistic, err error)
+	TopCourses(ctx context.Context, request *requests.TopCoursesRequest) (statistics []models.CourseStatistic, err error)
+}
@@ -0,0 +1,44 @@
+package contracts
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+)
+
+type TagDBRepository interface {
+	// FetchCoursesByTag list the id of every course carrying the tag or one of its descendants, the name may be a synonym;
+	// an unknown tag carries no course
+	FetchCoursesByTag(ctx context.Context, name string) (coursesID []primitive.ObjectID, err error)
+	// FetchByName find a tag by its name or one of its synonyms, the tag is answered with its synonyms
+	FetchByName(ctx context.Context, name string) (tag models.Tag, err error)
+	// SetParent move the tag under 'parent', a nil parent makes it a root;
+	// the tag itself or one of its descendants can't be its parent
+	SetParent(ctx context.Context, tagID primitive.ObjectID, parent *primitive.ObjectID) error
+	// AddSynonym a synonym which already names a tag or is the synonym of another tag is a conflict
+	AddSynonym(ctx context.Context, tagID primitive.ObjectID, synonym string) error
+	RemoveSynonym(ctx context.Context, tagID primitive.ObjectID, synonym string) error
+	// AttachCourse add the course to the tag, a synonym attaches to its tag and an unknown name creates the tag
+	AttachCourse(ctx context.Context, name string, courseID primitive.ObjectID) (tag models.Tag, err error)
+	// AttachCourses attach the courses of every tag in one bulk write, names are resolved and created like AttachCourse;
+	// 'failures' has the error of each tag which failed, nil for the applied ones
+	AttachCourses(ctx context.Context, tags []models.TagCourses) (failures []error, err error)
+	// Search list the tags whose search name starts with 'prefix', the tags carrying the most courses first
+	Search(ctx context.Context, prefix string, limit int64) (tags []models.TagSuggestion, err error)
+}
+
+type TagUsecase interface {
+	FetchByName(ctx context.Context, name string) (tag models.Tag, err error)
+	// SetParent an empty parent in the request makes the tag a root
+	SetParent(ctx context.Context, name string, request *requests.TagParentRequest) (status bool, err error)
+	AddSynonym(ctx context.Context, name string, synonym string) (status bool, err error)
+	RemoveSynonym(ctx context.Context, name string, synonym string) (status bool, err error)
+	AttachCourse(ctx context.Context, name string, courseID string) (tag models.Tag, err error)
+	// Import tag courses from a 'csv' or 'jsonl' reader of course_id,tag lines, the course ids are checked with the Course service;
+	// a line failing doesn't stop the others and the report tells the outcome of each line
+	Import(ctx context.Context, reader io.Reader, format string) (report models.TagImportReport, err error)
+	// Search suggest the tags starting with the query whatever its case and accents, a zero limit suggests 10 tags
+	Search(ctx context.Context, request *requests.TagSearchRequest) (tags []models.TagSuggestion, err error)
+}
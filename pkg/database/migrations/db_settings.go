@@ -1,19 +1,40 @@
 package migrations
 
 import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
 	"context"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 )
 
-func (m Migration) MigrateSettings() {
-	m.CreateIndexes()
+// MigrateSettings creates the indexes and backfills derived fields, every step is tried even when one fails
+func (m Migration) MigrateSettings() error {
+	indexErr := m.CreateIndexes()
+	backfillErr := m.BackfillTagSearchNames()
+	if indexErr != nil {
+		return indexErr
+	}
+	if backfillErr != nil {
+		return backfillErr
+	}
 	log.Println("Migrates Settings Success")
+	return nil
 }
 
-func (m Migration) CreateIndexes() {
+// CreateIndexes creates every index, an index which fails doesn't stop the others;
+// the error tells how many failed, e.g. a unique index over duplicated values
+func (m Migration) CreateIndexes() error {
+
+	var failed []error
+	check := func(err error) {
+		if err != nil {
+			log.Println(err)
+			failed = append(failed, err)
+		}
+	}
 
 	//_, err := m.DB.GetCollection(m.DB.DbCollectionBookmarks).Indexes().DropOne(context.Background(), "user_id_1")
 	//if err != nil {
@@ -26,19 +47,21 @@ func (m Migration) CreateIndexes() {
 			Keys:    bson.D{{Key: "user_id", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		})
-	if err != nil {
-		log.Println(err)
-	}
+	check(err)
 
 	_, err = m.DB.GetCollection(m.DB.DbCollectionBookmarks).Indexes().CreateOne(context.Background(),
 		mongo.IndexModel{
 			Keys:    bson.D{{Key: "courses.id", Value: 1}},
 			Options: options.Index().SetUnique(false),
 		})
-	if err != nil {
-		log.Println(err)
+	check(err)
 
-	}
+	//bookmarks are filtered by the personal tags of their owner
+	_, err = m.DB.GetCollection(m.DB.DbCollectionBookmarks).Indexes().CreateOne(context.Background(),
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "courses.tags", Value: 1}},
+		})
+	check(err)
 
 	//set carts user id as unique
 	_, err = m.DB.GetCollection(m.DB.DbCollectionCarts).Indexes().CreateOne(context.Background(),
@@ -46,7 +69,126 @@ func (m Migration) CreateIndexes() {
 			Keys:    bson.D{{Key: "user_id", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		})
+	check(err)
+
+	//set tag name as unique
+	_, err = m.DB.GetCollection(m.DB.DbCollectionTags).Indexes().CreateOne(context.Background(),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+	check(err)
+
+	//courses of a tag include the courses of its descendants, found through 'parent'
+	_, err = m.DB.GetCollection(m.DB.DbCollectionTags).Indexes().CreateOne(context.Background(),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "parent", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		})
+	check(err)
+
+	//tags are searched by the prefix of their folded name
+	_, err = m.DB.GetCollection(m.DB.DbCollectionTags).Indexes().CreateOne(context.Background(),
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "search_name", Value: 1}},
+		})
+	check(err)
+
+	//set tag synonym as unique, a synonym resolves to a single tag
+	_, err = m.DB.GetCollection(m.DB.DbCollectionTagSynonyms).Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "synonym", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bson.D{{Key: "tag_id", Value: 1}},
+			},
+		})
+	check(err)
+
+	//course statistics look up carts by course id
+	_, err = m.DB.GetCollection(m.DB.DbCollectionCarts).Indexes().CreateOne(context.Background(),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "courses.id", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		})
+	check(err)
+
+	//purging a course looks up the carts which saved it for later
+	_, err = m.DB.GetCollection(m.DB.DbCollectionCarts).Indexes().CreateOne(context.Background(),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "saved_courses.id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		})
+	check(err)
+
+	//set recently viewed user id as unique
+	_, err = m.DB.GetCollection(m.DB.DbCollectionRecentlyViewed).Indexes().CreateOne(context.Background(),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+	check(err)
+
+	//a recommendation is cached per user until its 'expires_at'
+	_, err = m.DB.GetCollection(m.DB.DbCollectionRecommendations).Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		})
+	check(err)
+
+	//an idempotency key is unique per user, records expire at their 'expires_at'
+	_, err = m.DB.GetCollection(m.DB.DbCollectionIdempotency).Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		})
+	check(err)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d indexes couldn't be created, the first: %w", len(failed), failed[0])
+	}
+	return nil
+}
+
+// BackfillTagSearchNames fold the names of the tags written before they were searchable
+func (m Migration) BackfillTagSearchNames() error {
+
+	tags := m.DB.GetCollection(m.DB.DbCollectionTags)
+	cursor, err := tags.Find(context.Background(), bson.M{"search_name": bson.M{"$exists": false}}, options.Find().SetProjection(bson.M{"name": 1}))
 	if err != nil {
 		log.Println(err)
+		return err
 	}
+
+	var unsearchable []models.Tag
+	err = cursor.All(context.Background(), &unsearchable)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	for _, tag := range unsearchable {
+		_, err = tags.UpdateOne(context.Background(), bson.M{"_id": tag.ID}, bson.M{"$set": bson.M{"search_name": models.TagSearchName(tag.Name)}})
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+
+	return nil
 }
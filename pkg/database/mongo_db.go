@@ -10,30 +10,38 @@ import (
 )
 
 type Database struct {
-	DbUsername            string
-	DBPassword            string
-	DbName                string
-	DbHost                string
-	DbPort                string
-	DbCollectionBookmarks string
-	DbCollectionCarts     string
-	DbCollectionTags      string
-	collection            *mongo.Collection
-	connection            *mongo.Database
-	config                contracts.DBConfig
+	DbUsername                  string
+	DBPassword                  string
+	DbName                      string
+	DbHost                      string
+	DbPort                      string
+	DbCollectionBookmarks       string
+	DbCollectionCarts           string
+	DbCollectionTags            string
+	DbCollectionTagSynonyms     string
+	DbCollectionIdempotency     string
+	DbCollectionRecentlyViewed  string
+	DbCollectionRecommendations string
+	collection                  *mongo.Collection
+	connection                  *mongo.Database
+	config                      contracts.DBConfig
 }
 
 func Construct(config contracts.DBConfig) *Database {
 	return &Database{
-		DbUsername:            config.GetDBConfig()["USERNAME"],
-		DBPassword:            config.GetDBConfig()["PASSWORD"],
-		DbName:                config.GetDBConfig()["NAME"],
-		DbHost:                config.GetDBConfig()["HOST"],
-		DbPort:                config.GetDBConfig()["PORT"],
-		DbCollectionBookmarks: config.GetDBConfig()["COLLECTION_BOOKMARKS"],
-		DbCollectionCarts:     config.GetDBConfig()["COLLECTION_CARTS"],
-		DbCollectionTags:      config.GetDBConfig()["COLLECTION_TAGS"],
-		config:                config,
+		DbUsername:                  config.GetDBConfig()["USERNAME"],
+		DBPassword:                  config.GetDBConfig()["PASSWORD"],
+		DbName:                      config.GetDBConfig()["NAME"],
+		DbHost:                      config.GetDBConfig()["HOST"],
+		DbPort:                      config.GetDBConfig()["PORT"],
+		DbCollectionBookmarks:       config.GetDBConfig()["COLLECTION_BOOKMARKS"],
+		DbCollectionCarts:           config.GetDBConfig()["COLLECTION_CARTS"],
+		DbCollectionTags:            config.GetDBConfig()["COLLECTION_TAGS"],
+		DbCollectionTagSynonyms:     config.GetDBConfig()["COLLECTION_TAG_SYNONYMS"],
+		DbCollectionIdempotency:     config.GetDBConfig()["COLLECTION_IDEMPOTENCY"],
+		DbCollectionRecentlyViewed:  config.GetDBConfig()["COLLECTION_RECENTLY_VIEWED"],
+		DbCollectionRecommendations: config.GetDBConfig()["COLLECTION_RECOMMENDATIONS"],
+		config:                      config,
 	}
 }
 
@@ -77,8 +85,16 @@ func (db *Database) GetCollection(collection string) *mongo.Collection {
 		return db.connection.Collection(collection)
 	case db.DbCollectionTags:
 		return db.connection.Collection(collection)
+	case db.DbCollectionTagSynonyms:
+		return db.connection.Collection(collection)
 	case db.DbCollectionCarts:
 		return db.connection.Collection(collection)
+	case db.DbCollectionIdempotency:
+		return db.connection.Collection(collection)
+	case db.DbCollectionRecentlyViewed:
+		return db.connection.Collection(collection)
+	case db.DbCollectionRecommendations:
+		return db.connection.Collection(collection)
 	default:
 		return nil
 	}
@@ -0,0 +1,163 @@
+package domain_errors
+
+import (
+	"errors"
+	"google.golang.org/grpc/codes"
+	"net/http"
+)
+
+// Kinds of domain errors, match them with errors.Is
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrUnavailable     = errors.New("unavailable")
+	// ErrPreconditionFailed the document changed since the version the client expects
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrQuotaExceeded the write would grow a document past its limit
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrTooLarge the request is larger than what is accepted
+	ErrTooLarge = errors.New("too large")
+)
+
+// Error carries a domain kind, the message shown to clients and the underlying cause;
+// Details are shown to clients next to the message, nil for most kinds
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+	Details interface{}
+}
+
+// Quota is the details of a quota exceeded error
+type Quota struct {
+	Current int64 `json:"current"`
+	Limit   int64 `json:"limit"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func NotFound(message string, err error) error {
+	return &Error{Kind: ErrNotFound, Message: message, Err: err}
+}
+
+func Conflict(message string, err error) error {
+	return &Error{Kind: ErrConflict, Message: message, Err: err}
+}
+
+func InvalidArgument(message string, err error) error {
+	return &Error{Kind: ErrInvalidArgument, Message: message, Err: err}
+}
+
+func Unavailable(message string, err error) error {
+	return &Error{Kind: ErrUnavailable, Message: message, Err: err}
+}
+
+func PreconditionFailed(message string, err error) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: message, Err: err}
+}
+
+func TooLarge(message string, err error) error {
+	return &Error{Kind: ErrTooLarge, Message: message, Err: err}
+}
+
+// QuotaExceeded tells the 'current' size of a document and its 'limit'
+func QuotaExceeded(message string, current int64, limit int64) error {
+	return &Error{Kind: ErrQuotaExceeded, Message: message, Details: Quota{Current: current, Limit: limit}}
+}
+
+// Details returns the details which are safe to show to clients, nil when the error has none
+func Details(err error) interface{} {
+	var domainError *Error
+	if errors.As(err, &domainError) {
+		return domainError.Details
+	}
+	return nil
+}
+
+// Message returns the message which is safe to show to clients,
+// errors without a domain kind are internal and their cause is hidden
+func Message(err error) string {
+	var domainError *Error
+	if errors.As(err, &domainError) {
+		return domainError.Message
+	}
+	return "internal server error"
+}
+
+// Code returns the machine readable code of an error
+func Code(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "NOT_FOUND"
+	case errors.Is(err, ErrConflict):
+		return "CONFLICT"
+	case errors.Is(err, ErrInvalidArgument):
+		return "INVALID_ARGUMENT"
+	case errors.Is(err, ErrUnavailable):
+		return "UNAVAILABLE"
+	case errors.Is(err, ErrPreconditionFailed):
+		return "PRECONDITION_FAILED"
+	case errors.Is(err, ErrQuotaExceeded):
+		return "QUOTA_EXCEEDED"
+	case errors.Is(err, ErrTooLarge):
+		return "TOO_LARGE"
+	default:
+		return "INTERNAL"
+	}
+}
+
+// HTTPStatus maps an error to its http status code
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrQuotaExceeded):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCode maps an error to its gRPC status code
+func GRPCCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, ErrConflict):
+		return codes.AlreadyExists
+	case errors.Is(err, ErrInvalidArgument):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrUnavailable):
+		return codes.Unavailable
+	case errors.Is(err, ErrPreconditionFailed):
+		return codes.FailedPrecondition
+	case errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrTooLarge):
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
@@ -2,16 +2,22 @@ package controllers
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/http/requests"
 	"acourse_tag_cart_bookmark_service/pkg/http/responses"
 	"acourse_tag_cart_bookmark_service/pkg/models"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
+)
+
+const (
+	defaultPerPage = 25
+	maxPerPage     = 100
 )
 
 type BookmarkHandler struct {
@@ -20,74 +26,126 @@ type BookmarkHandler struct {
 
 func (h *BookmarkHandler) Fetch(c *gin.Context) {
 
-	var excludedField []string
-	if c.Query("exclude") != "" {
-		excludedField = strings.Split(c.Query("exclude"), ",")
+	projection, err := projectionFromQuery(c, models.Bookmark{})
+	if err != nil {
+		abortWithError(c, err)
+		return
 	}
 
-	page, ok := c.GetQuery("page")
-	if page == "" || !ok {
-		page = "1"
-	} else if page == "0" {
-		page = "1"
+	//Cursor mode is used when a cursor is given, page mode otherwise
+	paginate := models.Pagination{
+		Page:    1,
+		PerPage: defaultPerPage,
+		Cursor:  c.Query("cursor"),
 	}
 
-	qPage, err2 := strconv.ParseInt(page, 10, 64)
-	if err2 != nil {
-		return
+	if page := c.Query("page"); page != "" && page != "0" && paginate.Cursor == "" {
+		qPage, err := strconv.ParseInt(page, 10, 64)
+		if err != nil || qPage < 1 {
+			abortWithError(c, domain_errors.InvalidArgument("page must be a positive number", err))
+			return
+		}
+		paginate.Page = qPage
 	}
 
-	paginate := models.Pagination{
-		Page:    qPage,
-		PerPage: 25,
+	if perPage := c.Query("per_page"); perPage != "" {
+		qPerPage, err := strconv.ParseInt(perPage, 10, 64)
+		if err != nil || qPerPage < 1 || qPerPage > maxPerPage {
+			abortWithError(c, domain_errors.InvalidArgument(fmt.Sprintf("per_page must be between 1 and %d", maxPerPage), err))
+			return
+		}
+		paginate.PerPage = qPerPage
 	}
 
-	limit, skip := paginate.GetPagination()
-	bookmarks, err := h.BookmarkUsecase.Fetch(c.Request.Context(), excludedField, limit, skip)
+	var filterReq requests.BookmarkFilterRequest
+	if err := c.ShouldBindQuery(&filterReq); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
 
+	if !validDateRange(filterReq.CreatedFrom, filterReq.CreatedTo) || !validDateRange(filterReq.UpdatedFrom, filterReq.UpdatedTo) {
+		abortWithError(c, domain_errors.InvalidArgument("date range start must be earlier than its end", nil))
+		return
+	}
+
+	bookmarks, err := h.BookmarkUsecase.Fetch(c.Request.Context(), projection, &filterReq, &paginate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, responses.HttpPaginationResponse{
-		PerPage: paginate.PerPage,
-		Page:    paginate.Page,
-		HttpResponse: responses.HttpResponse{
-			Data:       bookmarks,
-			StatusCode: http.StatusOK,
-		},
-	})
+	pagination := responses.Pagination{
+		PerPage:    paginate.PerPage,
+		Total:      paginate.Total,
+		HasNext:    paginate.HasNext,
+		NextCursor: paginate.NextCursor,
+	}
+	if paginate.Cursor == "" {
+		pagination.Page = paginate.Page
+	}
+
+	responses.Paginated(c, http.StatusOK, bookmarks, pagination)
 }
 
 func (h BookmarkHandler) FetchById(c *gin.Context) {
-	bookmark, err := h.BookmarkUsecase.FetchById(c.Request.Context(), c.Param("id"), []string{})
+	projection, err := projectionFromQuery(c, models.Bookmark{})
 	if err != nil {
-		//return 404 not found
-		if err.Error() == mongo.ErrNoDocuments.Error() {
-			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
+		return
+	}
+
+	bookmark, err := h.BookmarkUsecase.FetchById(c.Request.Context(), c.Param("id"), projection)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, bookmark)
+	setETag(c, bookmark.Version)
+	responses.Success(c, http.StatusOK, bookmark)
 }
 
 func (h BookmarkHandler) FetchByUserID(c *gin.Context) {
-	bookmark, err := h.BookmarkUsecase.FetchByUserId(c.Request.Context(), c.Param("user_id"), []string{})
+	projection, err := projectionFromQuery(c, models.Bookmark{})
 	if err != nil {
-		//return 404 not found
-		if err.Error() == mongo.ErrNoDocuments.Error() {
-			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, bookmark)
+
+	//A personal tag keeps the courses carrying it only
+	var bookmark models.Bookmark
+	if tag := c.Query("tag"); tag != "" {
+		bookmark, err = h.BookmarkUsecase.FetchByUserTag(c.Request.Context(), c.Param("user_id"), tag, projection)
+	} else {
+		bookmark, err = h.BookmarkUsecase.FetchByUserId(c.Request.Context(), c.Param("user_id"), projection)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	setETag(c, bookmark.Version)
+	responses.Success(c, http.StatusOK, bookmark)
+}
+
+func (h BookmarkHandler) FetchCourses(c *gin.Context) {
+
+	var courseListReq requests.CourseListRequest
+	err := c.ShouldBindQuery(&courseListReq)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	courses, paginate, err := h.BookmarkUsecase.FetchCourses(c.Request.Context(), c.Param("user_id"), &courseListReq)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Paginated(c, http.StatusOK, courses, responses.Pagination{
+		PerPage: paginate.PerPage,
+		Page:    paginate.Page,
+		Total:   paginate.Total,
+		HasNext: paginate.HasNext,
+	})
 }
 
 func (h BookmarkHandler) Create(c *gin.Context) {
@@ -96,19 +154,16 @@ func (h BookmarkHandler) Create(c *gin.Context) {
 
 	err := c.ShouldBindJSON(&createRequest)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
+		return
 	}
 
 	bookmark, err := h.BookmarkUsecase.Create(c.Request.Context(), &createRequest)
 	if err != nil {
-		if mongo.IsDuplicateKeyError(err) {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, bookmark)
+	responses.Success(c, http.StatusOK, bookmark)
 	return
 }
 
@@ -119,30 +174,25 @@ func (h BookmarkHandler) AddCourse(c *gin.Context) {
 	err := c.ShouldBindJSON(&addCourse)
 	if err != nil {
 		log.Println("BOOKMARK HANDLER: AddCourse", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
 		return
 	}
 
-	_, err = h.BookmarkUsecase.AddCourse(c.Request.Context(), &addCourse, c.Param("user_id"))
+	addCourse.Version, err = ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := h.BookmarkUsecase.AddCourse(c.Request.Context(), &addCourse, c.Param("user_id"))
 	if err != nil {
-		//return 404 not found
-		if err.Error() == mongo.ErrNoDocuments.Error() {
-			log.Println("BOOKMARK HANDLER: AddCourse", err)
-			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
-			return
-		}
-		//return error on failed updates
-		if err.Error() == errors.New("document not modified").Error() {
-			log.Println("BOOKMARK HANDLER: AddCourse", err)
-			c.JSON(http.StatusConflict, gin.H{"error": "duplication occurs"})
-			return
-		}
 		log.Println("BOOKMARK HANDLER: AddCourse", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "success"})
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
 	return
 
 }
@@ -153,25 +203,168 @@ func (h BookmarkHandler) RevokeCourse(c *gin.Context) {
 
 	err := c.ShouldBindJSON(&revokeCourse)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
 		return
 	}
 
-	_, err = h.BookmarkUsecase.RevokeCourse(c.Request.Context(), &revokeCourse, c.Param("user_id"))
+	revokeCourse.Version, err = ifMatchVersion(c)
 	if err != nil {
-		//return 404 not found
-		if err.Error() == mongo.ErrNoDocuments.Error() {
-			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
-			return
-		}
-		if err.Error() == errors.New("document not modified").Error() {
-			c.JSON(http.StatusNotFound, gin.H{"error": "course id not found"})
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := h.BookmarkUsecase.RevokeCourse(c.Request.Context(), &revokeCourse, c.Param("user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+	return
+}
+
+// CreateForUser create the bookmark of the user in the path, 409 when the user already has one
+func (h BookmarkHandler) CreateForUser(c *gin.Context) {
+
+	var createRequest requests.CreateUserBookmarkRequest
+	err := c.ShouldBindJSON(&createRequest)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	for _, course := range createRequest.Courses {
+		if !primitive.IsValidObjectID(course.ID) {
+			abortWithError(c, domain_errors.InvalidArgument("course id must be a 24 characters hex string", nil))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+
+	bookmark, err := h.BookmarkUsecase.Create(c.Request.Context(), &requests.CreateBookmarkRequest{
+		UserID:  c.Param("user_id"),
+		Courses: createRequest.Courses,
+	})
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "success"})
-	return
+	setETag(c, bookmark.Version)
+	responses.Success(c, http.StatusCreated, bookmark)
+}
+
+// PutCourse add the course in the path to the user's bookmark, adding a bookmarked course again succeeds
+func (h BookmarkHandler) PutCourse(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err = h.BookmarkUsecase.AddCourse(c.Request.Context(), &requests.AddCourseBookmarkRequest{
+		UserID:  c.Param("user_id"),
+		Courses: []requests.Course{{ID: courseID}},
+		Version: version,
+	}, c.Param("user_id"))
+	if err != nil && !errors.Is(err, domain_errors.ErrConflict) {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+}
+
+// DeleteCourse remove the course in the path from the user's bookmark
+func (h BookmarkHandler) DeleteCourse(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err = h.BookmarkUsecase.RevokeCourse(c.Request.Context(), &requests.DeleteAttachedCourseRequest{
+		UserID:  c.Param("user_id"),
+		Courses: []requests.Course{{ID: courseID}},
+		Version: version,
+	}, c.Param("user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+}
+
+// SetCourseTags replace the personal tags of the bookmarked course in the path
+func (h BookmarkHandler) SetCourseTags(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var tagsRequest requests.CourseTagsRequest
+	err = c.ShouldBindJSON(&tagsRequest)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	tagsRequest.Version, err = ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := h.BookmarkUsecase.SetCourseTags(c.Request.Context(), c.Param("user_id"), courseID, &tagsRequest)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+}
+
+// ClearCourseTags remove the personal tags of the bookmarked course in the path
+func (h BookmarkHandler) ClearCourseTags(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err = h.BookmarkUsecase.SetCourseTags(c.Request.Context(), c.Param("user_id"), courseID, &requests.CourseTagsRequest{Version: version})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
 }
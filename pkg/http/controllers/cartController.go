@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/http/requests"
-	"errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
 )
 
@@ -16,89 +18,106 @@ type CartHandler struct {
 func (h CartHandler) FetchByUserID(c *gin.Context) {
 
 	if c.Param("user_id") == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id is not provided in url parameter",
-		})
+		abortWithError(c, domain_errors.InvalidArgument("user_id is not provided in url parameter", nil))
 		return
 	}
 
-	cart, err := h.CartUsecase.FetchByUserId(c.Request.Context(), c.Param("user_id"), []string{})
+	projection, err := projectionFromQuery(c, models.Cart{})
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "document not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abortWithError(c, err)
+		return
+	}
+
+	cart, err := h.CartUsecase.FetchByUserId(c.Request.Context(), c.Param("user_id"), projection)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, cart)
+	setETag(c, cart.Version)
+	responses.Success(c, http.StatusOK, cart)
 	return
 }
 
+func (h CartHandler) FetchCourses(c *gin.Context) {
+
+	if c.Param("user_id") == "" {
+		abortWithError(c, domain_errors.InvalidArgument("user_id is not provided in url parameter", nil))
+		return
+	}
+
+	var courseListReq requests.CourseListRequest
+	err := c.ShouldBindQuery(&courseListReq)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	courses, paginate, err := h.CartUsecase.FetchCourses(c.Request.Context(), c.Param("user_id"), &courseListReq)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Paginated(c, http.StatusOK, courses, responses.Pagination{
+		PerPage: paginate.PerPage,
+		Page:    paginate.Page,
+		Total:   paginate.Total,
+		HasNext: paginate.HasNext,
+	})
+}
+
 func (h CartHandler) FetchByID(c *gin.Context) {
 
 	if c.Param("id") == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "id is not provided in url parameter",
-		})
+		abortWithError(c, domain_errors.InvalidArgument("id is not provided in url parameter", nil))
 		return
 	}
 
-	cart, err := h.CartUsecase.FetchById(c.Request.Context(), c.Param("id"), []string{})
+	projection, err := projectionFromQuery(c, models.Cart{})
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "document not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, cart)
+
+	cart, err := h.CartUsecase.FetchById(c.Request.Context(), c.Param("id"), projection)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	setETag(c, cart.Version)
+	responses.Success(c, http.StatusOK, cart)
 	return
 }
 
 func (h CartHandler) AddCourse(c *gin.Context) {
 
 	if c.Param("user_id") == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id is not provided in url parameter",
-		})
+		abortWithError(c, domain_errors.InvalidArgument("user_id is not provided in url parameter", nil))
 		return
 	}
 
 	var addCourseReq requests.AddCourseCartRequest
 	err := c.ShouldBindJSON(&addCourseReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abortWithError(c, bindError(err))
 		return
 	}
 
-	status, err := h.CartUsecase.AddCourse(c.Request.Context(), &addCourseReq, c.Param("user_id"))
+	addCourseReq.Version, err = ifMatchVersion(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		abortWithError(c, err)
 		return
 	}
-	if !status {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  status,
-			"message": "failed to add listed course",
-		})
+
+	version, err := h.CartUsecase.AddCourse(c.Request.Context(), &addCourseReq, c.Param("user_id"))
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"status": status,
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{
+		Status: true,
 	})
 	return
 }
@@ -106,38 +125,148 @@ func (h CartHandler) AddCourse(c *gin.Context) {
 func (h CartHandler) RevokeCourse(c *gin.Context) {
 
 	if c.Param("user_id") == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id is not provided in url parameter",
-		})
+		abortWithError(c, domain_errors.InvalidArgument("user_id is not provided in url parameter", nil))
 		return
 	}
 
 	var revokeCourseReq requests.RevokeCourseCartRequest
 	err := c.ShouldBindJSON(&revokeCourseReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abortWithError(c, bindError(err))
 		return
 	}
 
-	status, err := h.CartUsecase.RevokeCourse(c.Request.Context(), &revokeCourseReq, c.Param("user_id"))
+	revokeCourseReq.Version, err = ifMatchVersion(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		abortWithError(c, err)
 		return
 	}
-	if !status {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  status,
-			"message": "failed to revoke listed course",
-		})
+
+	version, err := h.CartUsecase.RevokeCourse(c.Request.Context(), &revokeCourseReq, c.Param("user_id"))
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"status": status,
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{
+		Status: true,
 	})
 	return
 
 }
+
+// PutCourse set the seats of the course in the path in the user's cart, the cart is created on the first add;
+// the body is optional, without one the course is a single seat
+func (h CartHandler) PutCourse(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var putRequest requests.PutCartItemRequest
+	if c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(&putRequest)
+		if err != nil {
+			abortWithError(c, bindError(err))
+			return
+		}
+	}
+
+	putRequest.Version, err = ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := h.CartUsecase.PutCourse(c.Request.Context(), c.Param("user_id"), courseID, &putRequest)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+}
+
+// DeleteCourse remove the course in the path from the user's cart
+func (h CartHandler) DeleteCourse(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err = h.CartUsecase.RevokeCourse(c.Request.Context(), &requests.RevokeCourseCartRequest{
+		UserID:  c.Param("user_id"),
+		Courses: []requests.Course{{ID: courseID}},
+		Version: version,
+	}, c.Param("user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+}
+
+// Summary count the items and seats in the user's cart
+func (h CartHandler) Summary(c *gin.Context) {
+
+	summary, err := h.CartUsecase.Summary(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, summary)
+}
+
+// SaveForLater move the course in the path from the user's cart to the courses saved for later
+func (h CartHandler) SaveForLater(c *gin.Context) {
+	h.savedCourse(c, h.CartUsecase.SaveForLater)
+}
+
+// MoveToCart move the course in the path from the courses saved for later back to the user's cart
+func (h CartHandler) MoveToCart(c *gin.Context) {
+	h.savedCourse(c, h.CartUsecase.MoveToCart)
+}
+
+// RemoveSaved remove the course in the path from the courses saved for later
+func (h CartHandler) RemoveSaved(c *gin.Context) {
+	h.savedCourse(c, h.CartUsecase.RemoveSaved)
+}
+
+func (h CartHandler) savedCourse(c *gin.Context, change func(ctx context.Context, userID string, courseID string, version int64) (int64, error)) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	version, err = change(c.Request.Context(), c.Param("user_id"), courseID, version)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	setETag(c, version)
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+}
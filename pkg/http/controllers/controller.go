@@ -2,17 +2,40 @@ package controllers
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/middleware"
+	"acourse_tag_cart_bookmark_service/pkg/http/openapi"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func SetupHandler(router *gin.Engine, bookmarkUsecase *contracts.BookmarkUsecase, cartUsecase *contracts.CartUsecase) {
+func SetupHandler(router *gin.Engine, config contracts.AppConfig, bookmarkUsecase *contracts.BookmarkUsecase, cartUsecase *contracts.CartUsecase, statisticUsecase *contracts.StatisticUsecase, courseUsecase *contracts.CourseUsecase, idempotencyUsecase *contracts.IdempotencyUsecase, recentlyViewedUsecase *contracts.RecentlyViewedUsecase, recommendationUsecase *contracts.RecommendationUsecase, tagUsecase *contracts.TagUsecase, exportUsecase *contracts.ExportUsecase) {
 	bookmarkHandler := BookmarkHandler{BookmarkUsecase: *bookmarkUsecase}
 	cartHandler := CartHandler{CartUsecase: *cartUsecase}
+	statisticHandler := StatisticHandler{StatisticUsecase: *statisticUsecase}
+	courseHandler := CourseHandler{CourseUsecase: *courseUsecase}
+	recentlyViewedHandler := RecentlyViewedHandler{RecentlyViewedUsecase: *recentlyViewedUsecase}
+	recommendationHandler := RecommendationHandler{RecommendationUsecase: *recommendationUsecase}
+	tagHandler := TagHandler{TagUsecase: *tagUsecase}
+	exportHandler := ExportHandler{ExportUsecase: *exportUsecase}
+
+	router.Use(middleware.RequestID(), middleware.Idempotency(*idempotencyUsecase))
 
 	bRoute := router.Group("/bookmark")
 	bRoute.GET("/", bookmarkHandler.Fetch)
 	bRoute.GET("/:id", bookmarkHandler.FetchById)
 	bRoute.GET("/u/:user_id", bookmarkHandler.FetchByUserID)
+	bRoute.GET("/u/:user_id/courses", bookmarkHandler.FetchCourses)
 	//bRoute.POST("/create", bookmarkHandler.Create)
 	bRoute.DELETE("/course/delete/:user_id", bookmarkHandler.RevokeCourse)
 	bRoute.PATCH("/course/add/:user_id", bookmarkHandler.AddCourse)
@@ -20,11 +43,169 @@ func SetupHandler(router *gin.Engine, bookmarkUsecase *contracts.BookmarkUsecase
 	cRoute := router.Group("/cart")
 	cRoute.GET("/:id", cartHandler.FetchByID)
 	cRoute.GET("/u/:user_id", cartHandler.FetchByUserID)
+	cRoute.GET("/u/:user_id/courses", cartHandler.FetchCourses)
 	cRoute.PATCH("/course/add/:user_id", cartHandler.AddCourse)
 	cRoute.DELETE("/course/revoke/:user_id", cartHandler.RevokeCourse)
 
+	router.GET("/recommendations/u/:user_id", recommendationHandler.Recommend)
+
+	router.GET("/tags/:name", tagHandler.FetchByName)
+	router.GET("/tag/search", tagHandler.Search)
+
+	router.GET("/users/:user_id/export", exportHandler.Export)
+
+	sRoute := router.Group("/statistic")
+	sRoute.GET("/courses", statisticHandler.CountByCourses)
+	sRoute.GET("/courses/:course_id", statisticHandler.CountByCourses)
+	sRoute.GET("/top", statisticHandler.TopCourses)
+
+	//Internal routes are called by other services only
+	iRoute := router.Group("/internal", middleware.Whitelist(strings.Split(config.GetAppConfig()["INTERNAL_WHITELIST"], ",")...))
+	iRoute.DELETE("/course/:course_id", courseHandler.PurgeCourse)
+	iRoute.POST("/tags/import", tagHandler.Import)
+	iRoute.PUT("/tags/:name/parent", tagHandler.SetParent)
+	iRoute.PUT("/tags/:name/synonyms/:synonym", tagHandler.AddSynonym)
+	iRoute.DELETE("/tags/:name/synonyms/:synonym", tagHandler.RemoveSynonym)
+	iRoute.PUT("/tags/:name/courses/:course_id", tagHandler.AttachCourse)
+
+	//Resource oriented routes, the v1 routes above are kept while clients migrate
+	v2Route := router.Group("/v2")
+	v2Route.GET("/bookmarks", bookmarkHandler.Fetch)
+	v2Route.GET("/bookmarks/:id", bookmarkHandler.FetchById)
+	v2Route.GET("/carts/:id", cartHandler.FetchByID)
+
+	uRoute := v2Route.Group("/users/:user_id")
+	uRoute.GET("/bookmarks", bookmarkHandler.FetchByUserID)
+	uRoute.POST("/bookmarks", bookmarkHandler.CreateForUser)
+	uRoute.GET("/bookmarks/items", bookmarkHandler.FetchCourses)
+	uRoute.PUT("/bookmarks/items/:course_id", bookmarkHandler.PutCourse)
+	uRoute.DELETE("/bookmarks/items/:course_id", bookmarkHandler.DeleteCourse)
+	uRoute.PUT("/bookmarks/items/:course_id/tags", bookmarkHandler.SetCourseTags)
+	uRoute.DELETE("/bookmarks/items/:course_id/tags", bookmarkHandler.ClearCourseTags)
+	uRoute.GET("/cart", cartHandler.FetchByUserID)
+	uRoute.GET("/cart/items", cartHandler.FetchCourses)
+	uRoute.GET("/cart/summary", cartHandler.Summary)
+	uRoute.PUT("/cart/items/:course_id", cartHandler.PutCourse)
+	uRoute.DELETE("/cart/items/:course_id", cartHandler.DeleteCourse)
+	uRoute.PUT("/cart/saved/:course_id", cartHandler.SaveForLater)
+	uRoute.DELETE("/cart/saved/:course_id", cartHandler.RemoveSaved)
+	uRoute.POST("/cart/saved/:course_id/restore", cartHandler.MoveToCart)
+	uRoute.GET("/recently-viewed", recentlyViewedHandler.Fetch)
+	uRoute.PUT("/recently-viewed/:course_id", recentlyViewedHandler.Record)
+
+	//API description
+	docs := openapi.ConstructHandler(apiInfo, router, Operations())
+	router.GET("/openapi.json", docs.Spec)
+	router.GET("/docs", docs.UI)
+	router.GET("/docs/assets/*file", docs.Assets)
+
 	router.NoRoute(func(c *gin.Context) {
-		c.JSON(404, gin.H{"code": "PAGE_NOT_FOUND", "message": "Page not found"})
+		responses.Failure(c, http.StatusNotFound, "PAGE_NOT_FOUND", "Page not found", nil)
 	})
 
 }
+
+// validDateRange a range is valid when one of its sides is open or the start is earlier than the end
+func validDateRange(from *time.Time, to *time.Time) bool {
+	return from == nil || to == nil || from.Before(*to)
+}
+
+// projectionFromQuery read the 'fields' and 'exclude' query params, both are comma separated json field names of the model
+func projectionFromQuery(c *gin.Context, model interface{}) (models.Projection, error) {
+
+	var fields, exclude []string
+	if c.Query("fields") != "" {
+		fields = strings.Split(c.Query("fields"), ",")
+	}
+	if c.Query("exclude") != "" {
+		exclude = strings.Split(c.Query("exclude"), ",")
+	}
+
+	return models.NewProjection(model, fields, exclude)
+}
+
+// abortWithError write the status, code and message mapped from a domain error
+func abortWithError(c *gin.Context, err error) {
+	responses.Error(c, err)
+}
+
+// limitBody reads at most 'max' bytes of a body, reading past them fails with a too large error
+func limitBody(body io.Reader, max int64) io.Reader {
+	return &limitedBody{body: body, max: max, remaining: max}
+}
+
+type limitedBody struct {
+	body      io.Reader
+	max       int64
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+
+	if l.remaining < 0 {
+		return 0, l.tooLarge()
+	}
+
+	//One byte more than allowed is read to tell a body of exactly 'max' bytes from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.body.Read(p)
+	if int64(n) > l.remaining {
+		l.remaining = -1
+		return 0, l.tooLarge()
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *limitedBody) tooLarge() error {
+	return domain_errors.TooLarge(fmt.Sprintf("body is larger than %d bytes", l.max), nil)
+}
+
+// bindError a request which fails binding or validation is an invalid argument
+func bindError(err error) error {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return domain_errors.InvalidArgument("request validation failed", err)
+	}
+	return domain_errors.InvalidArgument(err.Error(), err)
+}
+
+// courseIDParam read the 'course_id' path param of v2 item routes
+func courseIDParam(c *gin.Context) (string, error) {
+	courseID := c.Param("course_id")
+	if !primitive.IsValidObjectID(courseID) {
+		return "", domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
+	}
+	return courseID, nil
+}
+
+// setETag answer the version of a document as its ETag, documents written before versions existed have none
+func setETag(c *gin.Context, version int64) {
+	if version > 0 {
+		c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+}
+
+// ifMatchVersion read the version expected by the If-Match header, zero when the header is absent or '*';
+// an ETag which isn't a version can't match any document
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, domain_errors.PreconditionFailed("If-Match must be an ETag answered by this service", err)
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version < 1 {
+		return 0, domain_errors.PreconditionFailed("If-Match must be an ETag answered by this service", err)
+	}
+
+	return version, nil
+}
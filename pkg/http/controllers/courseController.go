@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type CourseHandler struct {
+	CourseUsecase contracts.CourseUsecase
+}
+
+func (h CourseHandler) PurgeCourse(c *gin.Context) {
+
+	if c.Param("course_id") == "" {
+		abortWithError(c, domain_errors.InvalidArgument("course_id is not provided in url parameter", nil))
+		return
+	}
+
+	purge, err := h.CourseUsecase.PurgeCourse(c.Request.Context(), c.Param("course_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, purge)
+}
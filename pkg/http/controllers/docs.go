@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/openapi"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"net/http"
+	"strings"
+)
+
+var apiInfo = openapi.Info{Title: "acourse tag cart bookmark service", Version: "1.0.0"}
+
+// projectionParams are accepted by every read endpoint, see projectionFromQuery
+var projectionParams = []openapi.Parameter{
+	{Name: "fields", In: "query", Description: "comma separated fields to include", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "exclude", In: "query", Description: "comma separated fields to exclude", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var idempotencyKeyParam = openapi.Parameter{
+	Name:        "Idempotency-Key",
+	In:          "header",
+	Description: "retries with the same key replay the first response, reusing a key with a different request is a conflict",
+	Schema:      &openapi.Schema{Type: "string", MaxLength: &maxIdempotencyKeyLength},
+}
+
+var maxIdempotencyKeyLength int64 = 255
+
+var ifMatchParam = openapi.Parameter{
+	Name:        "If-Match",
+	In:          "header",
+	Description: "ETag of the bookmark or cart, answered by its reads and changes; the change is refused with 412 when the document has changed since",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+// Operations documents every route of SetupHandler, a route without an operation fails the openapi test
+func Operations() map[string]openapi.Operation {
+
+	get := func(path string) string { return openapi.Key(http.MethodGet, path) }
+	patch := func(path string) string { return openapi.Key(http.MethodPatch, path) }
+	del := func(path string) string { return openapi.Key(http.MethodDelete, path) }
+	post := func(path string) string { return openapi.Key(http.MethodPost, path) }
+	put := func(path string) string { return openapi.Key(http.MethodPut, path) }
+
+	operations := map[string]openapi.Operation{
+		//Bookmarks
+		get("/bookmark/"): {
+			Summary: "List bookmarks, paginated by page or by cursor",
+			Tags:    []string{"bookmark"},
+			Query:   requests.BookmarkFilterRequest{},
+			Params: append([]openapi.Parameter{
+				{Name: "page", In: "query", Schema: &openapi.Schema{Type: "integer", Format: "int64"}},
+				{Name: "per_page", In: "query", Schema: &openapi.Schema{Type: "integer", Format: "int64"}},
+				{Name: "cursor", In: "query", Description: "next_cursor of the previous page", Schema: &openapi.Schema{Type: "string"}},
+			}, projectionParams...),
+			Response: []models.Bookmark{},
+		},
+		get("/bookmark/:id"): {
+			Summary:  "Fetch a bookmark by its id",
+			Tags:     []string{"bookmark"},
+			Params:   projectionParams,
+			Response: models.Bookmark{},
+		},
+		get("/bookmark/u/:user_id"): {
+			Summary: "Fetch the bookmark of a user",
+			Tags:    []string{"bookmark"},
+			Params: append([]openapi.Parameter{
+				{Name: "tag", In: "query", Description: "personal tag, only the courses carrying it are listed", Schema: &openapi.Schema{Type: "string"}},
+			}, projectionParams...),
+			Response: models.Bookmark{},
+		},
+		get("/bookmark/u/:user_id/courses"): {
+			Summary:  "List the bookmarked courses of a user, sorting or filtering by name is limited to lists of at most 1000 courses",
+			Tags:     []string{"bookmark"},
+			Query:    requests.CourseListRequest{},
+			Response: []models.Course{},
+		},
+		del("/bookmark/course/delete/:user_id"): {
+			Summary:  "Remove courses from the bookmark of a user",
+			Tags:     []string{"bookmark"},
+			Body:     requests.DeleteAttachedCourseRequest{},
+			Response: responses.Status{},
+		},
+		patch("/bookmark/course/add/:user_id"): {
+			Summary:  "Add courses to the bookmark of a user, the bookmark is created on the first add",
+			Tags:     []string{"bookmark"},
+			Body:     requests.AddCourseBookmarkRequest{},
+			Response: responses.Status{},
+		},
+
+		//Carts
+		get("/cart/:id"): {
+			Summary:  "Fetch a cart by its id",
+			Tags:     []string{"cart"},
+			Params:   projectionParams,
+			Response: models.Cart{},
+		},
+		get("/cart/u/:user_id"): {
+			Summary:  "Fetch the cart of a user",
+			Tags:     []string{"cart"},
+			Params:   projectionParams,
+			Response: models.Cart{},
+		},
+		get("/cart/u/:user_id/courses"): {
+			Summary:  "List the courses in the cart of a user, sorting or filtering by name is limited to lists of at most 1000 courses",
+			Tags:     []string{"cart"},
+			Query:    requests.CourseListRequest{},
+			Response: []models.Course{},
+		},
+		patch("/cart/course/add/:user_id"): {
+			Summary:  "Add courses to the cart of a user with their seats, the cart is created on the first add",
+			Tags:     []string{"cart"},
+			Body:     requests.AddCourseCartRequest{},
+			Response: responses.Status{},
+		},
+		del("/cart/course/revoke/:user_id"): {
+			Summary:  "Remove courses from the cart of a user",
+			Tags:     []string{"cart"},
+			Body:     requests.RevokeCourseCartRequest{},
+			Response: responses.Status{},
+		},
+
+		//Recommendations
+		get("/recommendations/u/:user_id"): {
+			Summary:  "Recommend courses bookmarked together with the courses of a user, boosted by shared tags; results are cached per user",
+			Tags:     []string{"recommendation"},
+			Query:    requests.RecommendationRequest{},
+			Response: []models.RecommendedCourse{},
+		},
+
+		//Statistics
+		get("/statistic/courses"): {
+			Summary: "Count the bookmarks and carts of courses",
+			Tags:    []string{"statistic"},
+			Params: []openapi.Parameter{
+				{Name: "course_id", In: "query", Required: true, Description: "comma separated course ids", Schema: &openapi.Schema{Type: "string"}},
+			},
+			Response: []models.CourseStatistic{},
+		},
+		get("/statistic/courses/:course_id"): {
+			Summary:  "Count the bookmarks and carts of a course",
+			Tags:     []string{"statistic"},
+			Response: []models.CourseStatistic{},
+		},
+		get("/statistic/top"): {
+			Summary:  "Rank the most bookmarked or carted courses",
+			Tags:     []string{"statistic"},
+			Query:    requests.TopCoursesRequest{},
+			Response: []models.CourseStatistic{},
+		},
+
+		//Internal
+		del("/internal/course/:course_id"): {
+			Summary:  "Purge a deleted course from every bookmark and cart",
+			Tags:     []string{"internal"},
+			Response: models.CoursePurge{},
+		},
+		post("/internal/tags/import"): {
+			Summary: "Tag courses from a CSV or JSONL body of course_id,tag lines, each line is reported applied or failed",
+			Tags:    []string{"internal"},
+			Params: []openapi.Parameter{
+				{Name: "format", In: "query", Description: "csv or jsonl, read from the Content-Type when absent", Schema: &openapi.Schema{Type: "string"}},
+			},
+			Response: models.TagImportReport{},
+		},
+		put("/internal/tags/:name/parent"): {
+			Summary:  "Move a tag under another tag, a parent among the tag's descendants is refused",
+			Tags:     []string{"internal"},
+			Body:     requests.TagParentRequest{},
+			Response: responses.Status{},
+		},
+		put("/internal/tags/:name/synonyms/:synonym"): {
+			Summary:  "Add a synonym of a tag, it is resolved to the tag on lookup and attach",
+			Tags:     []string{"internal"},
+			Response: responses.Status{},
+		},
+		del("/internal/tags/:name/synonyms/:synonym"): {
+			Summary:  "Remove a synonym of a tag",
+			Tags:     []string{"internal"},
+			Response: responses.Status{},
+		},
+		put("/internal/tags/:name/courses/:course_id"): {
+			Summary:  "Tag a course, a synonym tags with its tag and an unknown name creates the tag",
+			Tags:     []string{"internal"},
+			Response: models.Tag{},
+		},
+
+		//Tags
+		get("/tags/:name"): {
+			Summary:  "Fetch a tag by its name or one of its synonyms",
+			Tags:     []string{"tag"},
+			Response: models.Tag{},
+		},
+		get("/tag/search"): {
+			Summary:  "Suggest the tags starting with a prefix whatever its case and accents, the tags carrying the most courses first",
+			Tags:     []string{"tag"},
+			Query:    requests.TagSearchRequest{},
+			Response: []models.TagSuggestion{},
+		},
+
+		//Exports
+		get("/users/:user_id/export"): {
+			Summary: "Download everything stored about a user as a JSON document or a ZIP of one JSON file per section",
+			Tags:    []string{"export"},
+			Params: []openapi.Parameter{
+				{Name: "format", In: "query", Description: "json (default) or zip", Schema: &openapi.Schema{Type: "string"}},
+			},
+		},
+
+		//Documentation
+		get("/openapi.json"): {
+			Summary: "This document",
+			Tags:    []string{"documentation"},
+		},
+		get("/docs"): {
+			Summary: "Swagger UI of this document",
+			Tags:    []string{"documentation"},
+		},
+		get("/docs/assets/*file"): {
+			Summary: "Files of the Swagger UI, they are embedded in the service",
+			Tags:    []string{"documentation"},
+		},
+
+		//v2 bookmarks
+		post("/v2/users/:user_id/bookmarks"): {
+			Summary:  "Create the bookmark of a user",
+			Tags:     []string{"v2 bookmark"},
+			Body:     requests.CreateUserBookmarkRequest{},
+			Response: models.Bookmark{},
+		},
+		put("/v2/users/:user_id/bookmarks/items/:course_id"): {
+			Summary:  "Bookmark a course, bookmarking it again succeeds",
+			Tags:     []string{"v2 bookmark"},
+			Response: responses.Status{},
+		},
+		del("/v2/users/:user_id/bookmarks/items/:course_id"): {
+			Summary:  "Remove a course from the bookmark of a user",
+			Tags:     []string{"v2 bookmark"},
+			Response: responses.Status{},
+		},
+		put("/v2/users/:user_id/bookmarks/items/:course_id/tags"): {
+			Summary:  "Replace the personal tags of a bookmarked course, tags are lower cased",
+			Tags:     []string{"v2 bookmark"},
+			Body:     requests.CourseTagsRequest{},
+			Response: responses.Status{},
+		},
+		del("/v2/users/:user_id/bookmarks/items/:course_id/tags"): {
+			Summary:  "Clear the personal tags of a bookmarked course",
+			Tags:     []string{"v2 bookmark"},
+			Response: responses.Status{},
+		},
+
+		//v2 carts
+		get("/v2/users/:user_id/cart/summary"): {
+			Summary:  "Count the items and seats in the cart of a user",
+			Tags:     []string{"v2 cart"},
+			Response: models.CartSummary{},
+		},
+		put("/v2/users/:user_id/cart/items/:course_id"): {
+			Summary:  "Set the seats of a course in the cart of a user, the cart is created on the first add; without a body the course is a single seat",
+			Tags:     []string{"v2 cart"},
+			Body:     requests.PutCartItemRequest{},
+			Response: responses.Status{},
+		},
+		del("/v2/users/:user_id/cart/items/:course_id"): {
+			Summary:  "Remove a course from the cart of a user",
+			Tags:     []string{"v2 cart"},
+			Response: responses.Status{},
+		},
+		put("/v2/users/:user_id/cart/saved/:course_id"): {
+			Summary:  "Save a course of the cart for later, it keeps its seats and isn't counted by the summary; saving it again succeeds",
+			Tags:     []string{"v2 cart"},
+			Response: responses.Status{},
+		},
+		del("/v2/users/:user_id/cart/saved/:course_id"): {
+			Summary:  "Remove a course from the courses saved for later",
+			Tags:     []string{"v2 cart"},
+			Response: responses.Status{},
+		},
+		post("/v2/users/:user_id/cart/saved/:course_id/restore"): {
+			Summary:  "Move a course saved for later back to the cart with its seats",
+			Tags:     []string{"v2 cart"},
+			Response: responses.Status{},
+		},
+
+		//v2 recently viewed
+		get("/v2/users/:user_id/recently-viewed"): {
+			Summary:  "List the courses a user viewed last, most recent first",
+			Tags:     []string{"v2 recently viewed"},
+			Query:    requests.RecentlyViewedRequest{},
+			Response: models.RecentlyViewed{},
+		},
+		put("/v2/users/:user_id/recently-viewed/:course_id"): {
+			Summary:  "Record a view of a course, it moves to the front and the oldest courses past the length are dropped",
+			Tags:     []string{"v2 recently viewed"},
+			Response: responses.Status{},
+		},
+	}
+
+	//v2 reads share the handlers of v1
+	aliases := map[string]string{
+		get("/v2/bookmarks"):                      get("/bookmark/"),
+		get("/v2/bookmarks/:id"):                  get("/bookmark/:id"),
+		get("/v2/users/:user_id/bookmarks"):       get("/bookmark/u/:user_id"),
+		get("/v2/users/:user_id/bookmarks/items"): get("/bookmark/u/:user_id/courses"),
+		get("/v2/carts/:id"):                      get("/cart/:id"),
+		get("/v2/users/:user_id/cart"):            get("/cart/u/:user_id"),
+		get("/v2/users/:user_id/cart/items"):      get("/cart/u/:user_id/courses"),
+	}
+	for v2, v1 := range aliases {
+		operation := operations[v1]
+		operation.Tags = []string{"v2 " + operation.Tags[0]}
+		operations[v2] = operation
+	}
+
+	//changes of an existing bookmark or cart can be conditional on its version
+	versioned := []string{
+		patch("/bookmark/course/add/:user_id"),
+		del("/bookmark/course/delete/:user_id"),
+		patch("/cart/course/add/:user_id"),
+		del("/cart/course/revoke/:user_id"),
+		put("/v2/users/:user_id/bookmarks/items/:course_id"),
+		del("/v2/users/:user_id/bookmarks/items/:course_id"),
+		put("/v2/users/:user_id/bookmarks/items/:course_id/tags"),
+		del("/v2/users/:user_id/bookmarks/items/:course_id/tags"),
+		put("/v2/users/:user_id/cart/items/:course_id"),
+		del("/v2/users/:user_id/cart/items/:course_id"),
+		put("/v2/users/:user_id/cart/saved/:course_id"),
+		del("/v2/users/:user_id/cart/saved/:course_id"),
+		post("/v2/users/:user_id/cart/saved/:course_id/restore"),
+	}
+	for _, key := range versioned {
+		operation := operations[key]
+		operation.Params = append(append([]openapi.Parameter{}, operation.Params...), ifMatchParam)
+		operations[key] = operation
+	}
+
+	//every mutation accepts an Idempotency-Key, see middleware.Idempotency
+	for key, operation := range operations {
+		if strings.HasPrefix(key, http.MethodGet+" ") {
+			continue
+		}
+		operation.Params = append(append([]openapi.Parameter{}, operation.Params...), idempotencyKeyParam)
+		operations[key] = operation
+	}
+
+	return operations
+}
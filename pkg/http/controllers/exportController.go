@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"mime"
+	"net/http"
+)
+
+type ExportHandler struct {
+	ExportUsecase contracts.ExportUsecase
+}
+
+// exportContentTypes are the formats of an export
+var exportContentTypes = map[string]string{"json": "application/json", "zip": "application/zip"}
+
+// Export stream everything stored about the user as a JSON or ZIP attachment, the 'format' query param defaults to json;
+// a failure once the body has started cuts the body short, which leaves an invalid JSON or ZIP
+func (h ExportHandler) Export(c *gin.Context) {
+
+	format := c.DefaultQuery("format", "json")
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		abortWithError(c, domain_errors.InvalidArgument("format must be json or zip", nil))
+		return
+	}
+
+	writer := &attachmentWriter{c: c, contentType: contentType, filename: "export-" + c.Param("user_id") + "." + format}
+	err := h.ExportUsecase.Export(c.Request.Context(), c.Param("user_id"), format, writer)
+	if err == nil {
+		return
+	}
+
+	if !writer.started {
+		abortWithError(c, err)
+		return
+	}
+
+	log.Println("HANDLER:", c.Request.Method, c.FullPath(), ">> export cut short:", err)
+	c.Abort()
+}
+
+// attachmentWriter sets the status and the attachment headers on the first write,
+// so a failure before any write is answered like any other error
+type attachmentWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *attachmentWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": w.filename}))
+		w.c.Status(http.StatusOK)
+		w.started = true
+	}
+	return w.c.Writer.Write(b)
+}
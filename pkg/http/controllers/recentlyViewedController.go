@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type RecentlyViewedHandler struct {
+	RecentlyViewedUsecase contracts.RecentlyViewedUsecase
+}
+
+// Record move the course in the path to the front of the user's recently viewed courses
+func (h RecentlyViewedHandler) Record(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	err = h.RecentlyViewedUsecase.Record(c.Request.Context(), c.Param("user_id"), courseID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, responses.Status{Status: true})
+}
+
+// Fetch list the courses the user viewed last, most recent first
+func (h RecentlyViewedHandler) Fetch(c *gin.Context) {
+
+	var recentlyViewedRequest requests.RecentlyViewedRequest
+	err := c.ShouldBindQuery(&recentlyViewedRequest)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	recentlyViewed, err := h.RecentlyViewedUsecase.Fetch(c.Request.Context(), c.Param("user_id"), &recentlyViewedRequest)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, recentlyViewed)
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type RecommendationHandler struct {
+	RecommendationUsecase contracts.RecommendationUsecase
+}
+
+// Recommend list the courses recommended to the user, best first
+func (h RecommendationHandler) Recommend(c *gin.Context) {
+
+	var recommendationRequest requests.RecommendationRequest
+	err := c.ShouldBindQuery(&recommendationRequest)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	courses, err := h.RecommendationUsecase.Recommend(c.Request.Context(), c.Param("user_id"), &recommendationRequest)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, courses)
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+type StatisticHandler struct {
+	StatisticUsecase contracts.StatisticUsecase
+}
+
+func (h StatisticHandler) CountByCourses(c *gin.Context) {
+
+	var coursesID []string
+	if c.Param("course_id") != "" {
+		coursesID = []string{c.Param("course_id")}
+	} else if c.Query("course_id") != "" {
+		coursesID = strings.Split(c.Query("course_id"), ",")
+	} else {
+		abortWithError(c, domain_errors.InvalidArgument("course_id is not provided", nil))
+		return
+	}
+
+	statistics, err := h.StatisticUsecase.CountByCourses(c.Request.Context(), coursesID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, statistics)
+}
+
+func (h StatisticHandler) TopCourses(c *gin.Context) {
+
+	var topCoursesReq requests.TopCoursesRequest
+	err := c.ShouldBindQuery(&topCoursesReq)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	if !validDateRange(topCoursesReq.Since, topCoursesReq.Until) {
+		abortWithError(c, domain_errors.InvalidArgument("since must be earlier than until", nil))
+		return
+	}
+
+	statistics, err := h.StatisticUsecase.TopCourses(c.Request.Context(), &topCoursesReq)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, statistics)
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"github.com/gin-gonic/gin"
+	"mime"
+	"net/http"
+)
+
+// maxTagImportBytes bounds the body of a tag import
+const maxTagImportBytes = 4 << 20
+
+type TagHandler struct {
+	TagUsecase contracts.TagUsecase
+}
+
+// FetchByName answer the tag named in the path, a synonym answers its tag
+func (h TagHandler) FetchByName(c *gin.Context) {
+
+	tag, err := h.TagUsecase.FetchByName(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, tag)
+}
+
+// Search suggest the tags starting with the 'q' query param, for autocomplete
+func (h TagHandler) Search(c *gin.Context) {
+
+	var tagSearchRequest requests.TagSearchRequest
+	err := c.ShouldBindQuery(&tagSearchRequest)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	tags, err := h.TagUsecase.Search(c.Request.Context(), &tagSearchRequest)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, tags)
+}
+
+// SetParent move the tag under the parent of the body
+func (h TagHandler) SetParent(c *gin.Context) {
+
+	var tagParentRequest requests.TagParentRequest
+	err := c.ShouldBindJSON(&tagParentRequest)
+	if err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	status, err := h.TagUsecase.SetParent(c.Request.Context(), c.Param("name"), &tagParentRequest)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, responses.Status{Status: status})
+}
+
+func (h TagHandler) AddSynonym(c *gin.Context) {
+
+	status, err := h.TagUsecase.AddSynonym(c.Request.Context(), c.Param("name"), c.Param("synonym"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, responses.Status{Status: status})
+}
+
+func (h TagHandler) RemoveSynonym(c *gin.Context) {
+
+	status, err := h.TagUsecase.RemoveSynonym(c.Request.Context(), c.Param("name"), c.Param("synonym"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, responses.Status{Status: status})
+}
+
+// AttachCourse tag the course in the path, a synonym tags with its tag
+func (h TagHandler) AttachCourse(c *gin.Context) {
+
+	courseID, err := courseIDParam(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	tag, err := h.TagUsecase.AttachCourse(c.Request.Context(), c.Param("name"), courseID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, tag)
+}
+
+// Import tag courses from the CSV or JSONL body, the 'format' query param or else the Content-Type tells which;
+// the report is answered even when some lines failed
+func (h TagHandler) Import(c *gin.Context) {
+
+	format := c.Query("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = "csv"
+		case "application/x-ndjson", "application/jsonl":
+			format = "jsonl"
+		default:
+			abortWithError(c, domain_errors.InvalidArgument("format must be given as a query param or a text/csv or application/x-ndjson Content-Type", nil))
+			return
+		}
+	}
+
+	report, err := h.TagUsecase.Import(c.Request.Context(), limitBody(c.Request.Body, maxTagImportBytes), format)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	responses.Success(c, http.StatusOK, report)
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+	// completeAttempts is how many times the response is stored before the key is released
+	completeAttempts = 2
+	// idempotencyStoreTimeout bounds the writes of a key, they don't stop when the client goes away
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
+// unreplayedHeaders belong to a single response, they aren't replayed
+var unreplayedHeaders = map[string]bool{"Content-Type": true, "Content-Length": true, http.CanonicalHeaderKey(requestIDHeader): true}
+
+// responseRecorder keeps a copy of the response body written by the handlers
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the first response of a mutation retried with the same Idempotency-Key;
+// keys are scoped by the route and the 'user_id' path param, or the caller's address on routes without a user,
+// reusing a key with a different request is a conflict, requests without the header and reads are passed through
+func Idempotency(usecase contracts.IdempotencyUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := c.GetHeader(idempotencyKeyHeader)
+		if usecase == nil || key == "" || !mutating(c.Request.Method) || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			responses.Error(c, domain_errors.InvalidArgument("Idempotency-Key is too long", nil))
+			return
+		}
+
+		//1. The fingerprint covers the method, the path, the expected version and the body, the body is restored for the handlers
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestBytes+1))
+		if err != nil {
+			responses.Error(c, domain_errors.InvalidArgument("request body can't be read", err))
+			return
+		}
+		if len(body) > maxIdempotentRequestBytes {
+			responses.Error(c, domain_errors.TooLarge(fmt.Sprintf("body is larger than %d bytes", maxIdempotentRequestBytes), nil))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n" + c.GetHeader("If-Match") + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		userID := c.Param("user_id")
+		if userID == "" {
+			userID = "caller " + c.RemoteIP()
+		}
+		key = c.FullPath() + " " + key
+
+		//2. Replay a known response
+		ctx, cancel := storeContext()
+		replay, err := usecase.Begin(ctx, userID, key, fingerprint)
+		cancel()
+		if err != nil {
+			responses.Error(c, err)
+			return
+		}
+		if replay != nil {
+			for name, values := range replay.Response.Header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header(idempotentReplayedHeader, "true")
+			c.Data(replay.Response.StatusCode, replay.Response.ContentType, replay.Response.Body)
+			c.Abort()
+			return
+		}
+
+		//3. Handle the first request and keep its response, server errors release the key so retries are handled again
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			abort(usecase, userID, key)
+			return
+		}
+
+		header := make(map[string][]string)
+		for name, values := range c.Writer.Header() {
+			if !unreplayedHeaders[http.CanonicalHeaderKey(name)] {
+				header[name] = values
+			}
+		}
+		response := models.IdempotentResponse{
+			StatusCode:  c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+		}
+
+		//4. A key left without a response would answer 'in progress' until it expires, so it is released when the response can't be stored
+		for attempt := 1; attempt <= completeAttempts; attempt++ {
+			ctx, cancel := storeContext()
+			err = usecase.Complete(ctx, userID, key, response)
+			cancel()
+			if err == nil {
+				return
+			}
+			log.Println("IDEMPOTENCY MIDDLEWARE: Complete >>", err)
+		}
+
+		abort(usecase, userID, key)
+	}
+}
+
+// storeContext bounds a write of a key; it doesn't derive from the request, a client which went away
+// still gets its key stored or released, retrying after a disconnect is what the key exists for
+func storeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+}
+
+// abort release a key so the request is handled again
+func abort(usecase contracts.IdempotencyUsecase, userID string, key string) {
+	ctx, cancel := storeContext()
+	defer cancel()
+
+	if err := usecase.Abort(ctx, userID, key); err != nil {
+		log.Println("IDEMPOTENCY MIDDLEWARE: Abort >>", err)
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
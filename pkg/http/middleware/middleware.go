@@ -1,5 +1,16 @@
 package middleware
 
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const requestIDHeader = "X-Request-ID"
+
 var whitelists = []string{"10.10.10.1"}
 
 type Authorization struct {
@@ -12,3 +23,49 @@ type Client struct {
 	IPAddress string
 	Authorization
 }
+
+// AllowedIPs is the default whitelist extended with 'ips', it is shared by the HTTP and gRPC internal endpoints
+func AllowedIPs(ips ...string) map[string]bool {
+
+	allowed := make(map[string]bool)
+	for _, ip := range append(append([]string{}, whitelists...), ips...) {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			allowed[ip] = true
+		}
+	}
+
+	return allowed
+}
+
+// Whitelist only lets through clients whose IP address is whitelisted, 'ips' extends the default whitelist;
+// the address is the one of the connection, forwarding headers are set by the client and can't be trusted
+func Whitelist(ips ...string) gin.HandlerFunc {
+
+	allowed := AllowedIPs(ips...)
+
+	return func(c *gin.Context) {
+		client := Client{IPAddress: c.RemoteIP()}
+		if !allowed[client.IPAddress] {
+			responses.Failure(c, http.StatusForbidden, "FORBIDDEN", "client is not allowed to access this resource", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequestID keeps the X-Request-ID sent by the client or generates a new one,
+// the id is echoed in the response header and in the envelope meta
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			buf := make([]byte, 16)
+			_, _ = rand.Read(buf)
+			requestID = hex.EncodeToString(buf)
+		}
+
+		c.Set(responses.RequestIDKey, requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
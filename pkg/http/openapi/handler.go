@@ -0,0 +1,50 @@
+package openapi
+
+import (
+	"embed"
+	"github.com/gin-gonic/gin"
+	"io/fs"
+	"net/http"
+	"sync"
+)
+
+//go:generate go run vendor_swagger_ui.go
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// swaggerAssets are the vendored swagger-ui-dist files, see vendor_swagger_ui.go
+//
+//go:embed swagger-ui
+var swaggerAssets embed.FS
+
+// Handler serves the document of a router, it is built on the first request once every route is registered
+type Handler struct {
+	info       Info
+	router     *gin.Engine
+	operations map[string]Operation
+
+	once sync.Once
+	doc  *Document
+}
+
+func ConstructHandler(info Info, router *gin.Engine, operations map[string]Operation) *Handler {
+	return &Handler{info: info, router: router, operations: operations}
+}
+
+func (h *Handler) Spec(c *gin.Context) {
+	h.once.Do(func() {
+		h.doc = Build(h.info, h.router.Routes(), h.operations)
+	})
+	c.JSON(http.StatusOK, h.doc)
+}
+
+func (h *Handler) UI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUI)
+}
+
+// Assets serves the swagger-ui files loaded by the UI page, the 'file' path param names one
+func (h *Handler) Assets(c *gin.Context) {
+	assets, _ := fs.Sub(swaggerAssets, "swagger-ui")
+	c.FileFromFS(c.Param("file"), http.FS(assets))
+}
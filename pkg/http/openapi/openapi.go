@@ -0,0 +1,431 @@
+package openapi
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation documents a route, the request and response types are described through reflection
+type Operation struct {
+	Summary string
+	Tags    []string
+
+	// Query is a struct bound with 'form' tags, each field becomes a query parameter
+	Query interface{}
+
+	// Params are query parameters which are not bound to a struct
+	Params []Parameter
+
+	// Body is a struct bound with 'json' tags
+	Body interface{}
+
+	// Response is the 'data' of the response envelope, nil when the route does not return an envelope
+	Response interface{}
+}
+
+// Key identifies an operation by its method and gin path, e.g. "GET /bookmark/:id"
+func Key(method string, path string) string {
+	return method + " " + path
+}
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	OperationID string              `json:"operationId"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int64             `json:"minLength,omitempty"`
+	MaxLength            *int64             `json:"maxLength,omitempty"`
+	MinItems             *int64             `json:"minItems,omitempty"`
+	MaxItems             *int64             `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf([12]byte{})
+)
+
+// Build describes every route of the table which has an operation, routes without one are left out,
+// use Undocumented to find them
+func Build(info Info, routes gin.RoutesInfo, operations map[string]Operation) *Document {
+
+	b := builder{schemas: make(map[string]*Schema)}
+
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: b.schemas},
+	}
+
+	envelopeSchema := b.schemaOf(reflect.TypeOf(responses.Envelope{}))
+
+	for _, route := range routes {
+		operation, ok := operations[Key(route.Method, route.Path)]
+		if !ok {
+			continue
+		}
+
+		oasPath, pathParams := convertPath(route.Path)
+
+		object := &OperationObject{
+			Summary:     operation.Summary,
+			Tags:        operation.Tags,
+			OperationID: operationID(route.Method, route.Path),
+			Responses:   make(map[string]Response),
+		}
+
+		//1. Path, struct bound and free query parameters
+		for _, name := range pathParams {
+			object.Parameters = append(object.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		if operation.Query != nil {
+			object.Parameters = append(object.Parameters, b.queryParameters(reflect.TypeOf(operation.Query))...)
+		}
+		object.Parameters = append(object.Parameters, operation.Params...)
+
+		//2. Json body
+		if operation.Body != nil {
+			object.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: b.schemaOf(reflect.TypeOf(operation.Body))}},
+			}
+		}
+
+		//3. Success responses are wrapped in the envelope, errors share the envelope with a null 'data'
+		if operation.Response != nil {
+			object.Responses[strconv.Itoa(http.StatusOK)] = Response{
+				Description: "success",
+				Content: map[string]MediaType{"application/json": {Schema: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"data":  b.schemaOf(reflect.TypeOf(operation.Response)),
+						"error": {Type: "object", Nullable: true},
+						"meta":  b.schemaOf(reflect.TypeOf(responses.Meta{})),
+					},
+				}}},
+			}
+		} else {
+			object.Responses[strconv.Itoa(http.StatusOK)] = Response{Description: "success"}
+		}
+		object.Responses["default"] = Response{
+			Description: "error",
+			Content:     map[string]MediaType{"application/json": {Schema: envelopeSchema}},
+		}
+
+		item, ok := doc.Paths[oasPath]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[oasPath] = item
+		}
+		item[strings.ToLower(route.Method)] = object
+	}
+
+	return doc
+}
+
+// Undocumented lists the routes of the table which have no operation
+func Undocumented(routes gin.RoutesInfo, operations map[string]Operation) []string {
+	missing := make([]string, 0)
+	for _, route := range routes {
+		if _, ok := operations[Key(route.Method, route.Path)]; !ok {
+			missing = append(missing, Key(route.Method, route.Path))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Unrouted lists the operations which match no route of the table
+func Unrouted(routes gin.RoutesInfo, operations map[string]Operation) []string {
+	routed := make(map[string]bool)
+	for _, route := range routes {
+		routed[Key(route.Method, route.Path)] = true
+	}
+
+	stale := make([]string, 0)
+	for key := range operations {
+		if !routed[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// convertPath turn a gin path into an OpenAPI path, ':id' and '*id' become '{id}'
+func convertPath(ginPath string) (string, []string) {
+	segments := strings.Split(ginPath, "/")
+	params := make([]string, 0)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func operationID(method string, ginPath string) string {
+	replacer := strings.NewReplacer("/", "_", ":", "", "*", "", ".", "_", "-", "_")
+	return strings.ToLower(method) + strings.TrimRight(replacer.Replace(path.Clean(ginPath)), "_")
+}
+
+type builder struct {
+	schemas map[string]*Schema
+}
+
+// schemaOf describes a type, named structs are registered once as components and referenced
+func (b builder) schemaOf(t reflect.Type) *Schema {
+
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: nullable}
+	case t.Kind() == reflect.Array && t.ConvertibleTo(objectIDType):
+		return &Schema{Type: "string", Format: "objectid", Nullable: nullable}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem()), Nullable: true}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := b.schemas[name]; !ok {
+			//register before describing the fields so recursive types terminate
+			b.schemas[name] = &Schema{}
+			*b.schemas[name] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+func (b builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for _, field := range structFields(t, "json") {
+		property := b.schemaOf(field.Type)
+		required := applyBinding(property, field.Tag.Get("binding"))
+		if required {
+			schema.Required = append(schema.Required, field.name)
+		}
+		schema.Properties[field.name] = property
+	}
+
+	return schema
+}
+
+func (b builder) queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	params := make([]Parameter, 0)
+	for _, field := range structFields(t, "form") {
+		schema := b.schemaOf(field.Type)
+		schema.Nullable = false
+		required := applyBinding(schema, field.Tag.Get("binding"))
+		params = append(params, Parameter{Name: field.name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
+
+type namedField struct {
+	reflect.StructField
+	name string
+}
+
+// structFields lists the exported fields named by 'tag', embedded structs are flattened
+func structFields(t reflect.Type, tag string) []namedField {
+	fields := make([]namedField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, structFields(field.Type, tag)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, namedField{StructField: field, name: name})
+	}
+	return fields
+}
+
+// applyBinding translate the validator rules of a 'binding' tag, rules after 'dive' apply to the items
+func applyBinding(schema *Schema, binding string) (required bool) {
+	target := schema
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = target == schema
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "len":
+			limit(target, param, param)
+		case "min":
+			limit(target, param, "")
+		case "max":
+			limit(target, "", param)
+		case "hexadecimal":
+			target.Description = strings.TrimSpace(target.Description + " hexadecimal")
+		case "email":
+			target.Format = "email"
+		}
+	}
+	return required
+}
+
+func limit(schema *Schema, min string, max string) {
+	parse := func(value string) *int64 {
+		if value == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil
+		}
+		return &n
+	}
+	asFloat := func(n *int64) *float64 {
+		if n == nil {
+			return nil
+		}
+		f := float64(*n)
+		return &f
+	}
+
+	lower, upper := parse(min), parse(max)
+
+	switch schema.Type {
+	case "string":
+		if lower != nil {
+			schema.MinLength = lower
+		}
+		if upper != nil {
+			schema.MaxLength = upper
+		}
+	case "array":
+		if lower != nil {
+			schema.MinItems = lower
+		}
+		if upper != nil {
+			schema.MaxItems = upper
+		}
+	case "integer", "number":
+		if lower != nil {
+			schema.Minimum = asFloat(lower)
+		}
+		if upper != nil {
+			schema.Maximum = asFloat(upper)
+		}
+	}
+}
+
+// componentName qualifies a type with its package, e.g. "models.Course" and "requests.Course"
+func componentName(t reflect.Type) string {
+	return fmt.Sprintf("%s.%s", path.Base(t.PkgPath()), t.Name())
+}
@@ -0,0 +1,57 @@
+//go:build ignore
+
+// vendor_swagger_ui downloads the swagger-ui-dist files of swagger-ui/VERSION into swagger-ui,
+// they are embedded so /docs loads nothing from another origin
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var files = []string{"swagger-ui.css", "swagger-ui-bundle.js", "LICENSE"}
+
+func main() {
+
+	version, err := os.ReadFile(filepath.Join("swagger-ui", "VERSION"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range files {
+		url := fmt.Sprintf("https://unpkg.com/swagger-ui-dist@%s/%s", strings.TrimSpace(string(version)), file)
+
+		err := download(url, filepath.Join("swagger-ui", file))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("vendored", url)
+	}
+}
+
+func download(url string, path string) error {
+
+	response, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", url, response.Status)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, response.Body)
+	return err
+}
@@ -1,5 +1,17 @@
 package requests
 
+import "time"
+
+type BookmarkFilterRequest struct {
+	UserIDPrefix string     `form:"user_id_prefix" binding:"omitempty,max=64"`
+	CourseID     string     `form:"course_id" binding:"omitempty,len=24,hexadecimal"`
+	CreatedFrom  *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo    *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedFrom  *time.Time `form:"updated_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedTo    *time.Time `form:"updated_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinCourses   int64      `form:"min_courses" binding:"omitempty,min=1,max=10000"`
+}
+
 type CreateBookmarkRequest struct {
 	UserID  string   `json:"user_id" binding:"required"`
 	Courses []Course `json:"courses" binding:"required,dive"`
@@ -8,13 +20,37 @@ type CreateBookmarkRequest struct {
 type AddCourseBookmarkRequest struct {
 	UserID  string   `json:"user_id" binding:"required"`
 	Courses []Course `json:"courses" binding:"required,dive"`
+	// Version is the expected version from the If-Match header, zero writes whatever the version
+	Version int64 `json:"-"`
 }
 
 type DeleteAttachedCourseRequest struct {
 	UserID  string   `json:"user_id" binding:"required"`
 	Courses []Course `json:"courses" binding:"required,dive"`
+	// Version is the expected version from the If-Match header, zero writes whatever the version
+	Version int64 `json:"-"`
 }
 
 type Course struct {
 	ID string `json:"id" binding:"required"`
 }
+
+type CourseListRequest struct {
+	Page    int64  `form:"page" binding:"omitempty,min=1"`
+	PerPage int64  `form:"per_page" binding:"omitempty,min=1,max=100"`
+	Sort    string `form:"sort" binding:"omitempty,oneof=added_at -added_at name -name"`
+	Name    string `form:"name"`
+	Tag     string `form:"tag"`
+}
+
+// CourseTagsRequest replaces the personal tags of a bookmarked course, no tag clears them
+type CourseTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=20,dive,min=1,max=32"`
+	// Version is the expected version from the If-Match header, zero writes whatever the version
+	Version int64 `json:"-"`
+}
+
+// CreateUserBookmarkRequest is the body of the v2 bookmark creation, the owner is taken from the path
+type CreateUserBookmarkRequest struct {
+	Courses []Course `json:"courses" binding:"dive"`
+}
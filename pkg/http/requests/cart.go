@@ -1,11 +1,31 @@
 package requests
 
 type AddCourseCartRequest struct {
-	UserID  string   `json:"user_id" binding:"required"`
-	Courses []Course `json:"courses" binding:"required,dive"`
+	UserID  string     `json:"user_id" binding:"required"`
+	Courses []CartItem `json:"courses" binding:"required,dive"`
+	// Version is the expected version from the If-Match header, zero writes whatever the version
+	Version int64 `json:"-"`
 }
 
 type RevokeCourseCartRequest struct {
 	UserID  string   `json:"user_id" binding:"required"`
 	Courses []Course `json:"courses" binding:"required,dive"`
+	// Version is the expected version from the If-Match header, zero writes whatever the version
+	Version int64 `json:"-"`
+}
+
+// CartItem is a course added to a cart, buying several seats or gifting them to recipients;
+// a gift has one recipient per seat, the quantity defaults to the number of recipients or to a single seat
+type CartItem struct {
+	ID         string   `json:"id" binding:"required"`
+	Quantity   int64    `json:"quantity,omitempty" binding:"omitempty,min=1,max=1000"`
+	Recipients []string `json:"recipients,omitempty" binding:"omitempty,max=1000,dive,email"`
+}
+
+// PutCartItemRequest is the optional body of the v2 cart item put, an empty body puts a single seat
+type PutCartItemRequest struct {
+	Quantity   int64    `json:"quantity,omitempty" binding:"omitempty,min=1,max=1000"`
+	Recipients []string `json:"recipients,omitempty" binding:"omitempty,max=1000,dive,email"`
+	// Version is the expected version from the If-Match header, zero writes whatever the version
+	Version int64 `json:"-"`
 }
@@ -0,0 +1,5 @@
+package requests
+
+type RecommendationRequest struct {
+	Limit int64 `form:"limit" binding:"omitempty,min=1,max=50"`
+}
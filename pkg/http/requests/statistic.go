@@ -0,0 +1,10 @@
+package requests
+
+import "time"
+
+type TopCoursesRequest struct {
+	By    string     `form:"by" binding:"omitempty,oneof=bookmarks carts"`
+	Limit int64      `form:"limit" binding:"omitempty,min=1,max=100"`
+	Since *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
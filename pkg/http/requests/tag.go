@@ -0,0 +1,11 @@
+package requests
+
+// TagParentRequest moves a tag under another tag by name, no parent makes it a root
+type TagParentRequest struct {
+	Parent string `json:"parent" binding:"max=64"`
+}
+
+type TagSearchRequest struct {
+	Q     string `form:"q" binding:"required,max=64"`
+	Limit int64  `form:"limit" binding:"omitempty,min=1,max=50"`
+}
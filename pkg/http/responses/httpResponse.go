@@ -1,13 +1,98 @@
 package responses
 
-type HttpResponse struct {
-	StatusCode int         `json:"status_code,omitempty"`
-	Message    string      `json:"message,omitempty"`
-	Data       interface{} `json:"data,omitempty"`
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/http"
+)
+
+// RequestIDKey is the gin context key holding the id of the current request
+const RequestIDKey = "request_id"
+
+// Envelope is the body of every response, 'data' is null on failures and 'error' is null on success
+type Envelope struct {
+	Data  interface{} `json:"data"`
+	Error *ErrorBody  `json:"error"`
+	Meta  Meta        `json:"meta"`
+}
+
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// FieldError describes a request field which failed validation
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
 }
 
-type HttpPaginationResponse struct {
-	PerPage int64 `json:"per_page"`
-	Page    int64 `json:"page"`
-	HttpResponse
+type Meta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+type Pagination struct {
+	PerPage    int64  `json:"per_page"`
+	Page       int64  `json:"page,omitempty"`
+	Total      int64  `json:"total"`
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Status is the data of mutations which return no resource
+type Status struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Success writes 'data' wrapped in an envelope
+func Success(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, Envelope{Data: data, Meta: Meta{RequestID: c.GetString(RequestIDKey)}})
+}
+
+// Paginated writes a page of 'data' wrapped in an envelope along with its pagination
+func Paginated(c *gin.Context, status int, data interface{}, pagination Pagination) {
+	c.JSON(status, Envelope{Data: data, Meta: Meta{RequestID: c.GetString(RequestIDKey), Pagination: &pagination}})
+}
+
+// Failure aborts the request and writes the error wrapped in an envelope
+func Failure(c *gin.Context, status int, code string, message string, details interface{}) {
+	c.AbortWithStatusJSON(status, Envelope{
+		Error: &ErrorBody{Code: code, Message: message, Details: details},
+		Meta:  Meta{RequestID: c.GetString(RequestIDKey)},
+	})
+}
+
+// Error aborts the request with the status, code and message mapped from a domain error,
+// errors without a domain kind are logged and answered with 500
+func Error(c *gin.Context, err error) {
+	status := domain_errors.HTTPStatus(err)
+	if status >= http.StatusInternalServerError {
+		log.Println("HANDLER:", c.Request.Method, c.FullPath(), ">>", err)
+	}
+	Failure(c, status, domain_errors.Code(err), domain_errors.Message(err), errorDetails(err))
+}
+
+// errorDetails lists the fields which failed validation, other errors answer the details of their domain error
+func errorDetails(err error) interface{} {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return domain_errors.Details(err)
+	}
+
+	details := make([]FieldError, 0)
+	for _, fieldError := range validationErrors {
+		details = append(details, FieldError{
+			Field: fieldError.Field(),
+			Rule:  fieldError.Tag(),
+			Param: fieldError.Param(),
+		})
+	}
+	return details
 }
@@ -6,15 +6,43 @@ import (
 )
 
 type Bookmark struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id"`
-	UserID    string             `json:"user_id" bson:"user_id"`
-	Courses   []Course           `json:"courses" bson:"courses"`
-	UpdatedAt *time.Time         `json:"updated_at,omitempty" bson:"updated_at"`
-	CreatedAt *time.Time         `json:"created_at,omitempty" bson:"created_at"`
-	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at"`
+	ID      primitive.ObjectID `json:"id" bson:"_id"`
+	UserID  string             `json:"user_id" bson:"user_id"`
+	Courses []Course           `json:"courses" bson:"courses"`
+	// Version is incremented by every write, it is answered as the ETag
+	Version   int64      `json:"version,omitempty" bson:"version"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" bson:"updated_at"`
+	CreatedAt *time.Time `json:"created_at,omitempty" bson:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at"`
 }
 
 type Course struct {
-	ID   primitive.ObjectID `json:"id" bson:"id"`
-	Name string             `json:"name,omitempty" bson:"-"`
+	ID      primitive.ObjectID `json:"id" bson:"id"`
+	Name    string             `json:"name,omitempty" bson:"-"`
+	AddedAt *time.Time         `json:"added_at,omitempty" bson:"added_at,omitempty"`
+	// Quantity is the number of seats of a cart item, items without one are a single seat
+	Quantity int64 `json:"quantity,omitempty" bson:"quantity,omitempty"`
+	// Recipients are the emails the seats of a gifted cart item are for, one per seat
+	Recipients []string `json:"recipients,omitempty" bson:"recipients,omitempty"`
+	// Tags are the owner's own labels on a bookmarked course, unlike the global tags they aren't shared
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+}
+
+// Seats is the number of seats of a cart item
+func (c Course) Seats() int64 {
+	if c.Quantity < 1 {
+		return 1
+	}
+	return c.Quantity
+}
+
+// BookmarkFilter narrows down a bookmark listing, zero values are ignored
+type BookmarkFilter struct {
+	UserIDPrefix string
+	CourseID     primitive.ObjectID
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	UpdatedFrom  *time.Time
+	UpdatedTo    *time.Time
+	MinCourses   int64
 }
@@ -6,10 +6,31 @@ import (
 )
 
 type Cart struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id"`
-	UserID    string             `json:"user_id" bson:"user_id"`
-	Courses   []Course           `json:"courses" bson:"courses"`
-	UpdatedAt *time.Time         `json:"updated_at,omitempty" bson:"updated_at"`
-	CreatedAt *time.Time         `json:"created_at,omitempty" bson:"created_at"`
-	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at"`
+	ID      primitive.ObjectID `json:"id" bson:"_id"`
+	UserID  string             `json:"user_id" bson:"user_id"`
+	Courses []Course           `json:"courses" bson:"courses"`
+	// SavedCourses are kept with the cart for later, they aren't checked out nor counted by the summary
+	SavedCourses []Course `json:"saved_courses,omitempty" bson:"saved_courses,omitempty"`
+	// Version is incremented by every write, it is answered as the ETag
+	Version   int64      `json:"version,omitempty" bson:"version"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" bson:"updated_at"`
+	CreatedAt *time.Time `json:"created_at,omitempty" bson:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at"`
+}
+
+// CartSummary counts the items and seats of a cart, only the active items are counted
+type CartSummary struct {
+	Items       int64 `json:"items"`
+	Seats       int64 `json:"seats"`
+	GiftedSeats int64 `json:"gifted_seats"`
+	SavedItems  int64 `json:"saved_items"`
+}
+
+func (c Cart) Summary() CartSummary {
+	summary := CartSummary{Items: int64(len(c.Courses)), SavedItems: int64(len(c.SavedCourses))}
+	for _, course := range c.Courses {
+		summary.Seats += course.Seats()
+		summary.GiftedSeats += int64(len(course.Recipients))
+	}
+	return summary
 }
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// UserExport heads the export of everything this service stores about a user, 'Sections' names the parts which follow
+type UserExport struct {
+	UserID     string    `json:"user_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	Sections   []string  `json:"sections"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// IdempotencyRecord remembers the first response to a mutation sent with an Idempotency-Key,
+// 'Response' is nil while the first request is still being handled
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id"`
+	UserID      string              `json:"user_id" bson:"user_id"`
+	Key         string              `json:"key" bson:"key"`
+	Fingerprint string              `json:"fingerprint" bson:"fingerprint"`
+	Response    *IdempotentResponse `json:"response,omitempty" bson:"response"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+	ExpiresAt   time.Time           `json:"expires_at" bson:"expires_at"`
+}
+
+type IdempotentResponse struct {
+	StatusCode  int    `json:"status_code" bson:"status_code"`
+	ContentType string `json:"content_type" bson:"content_type"`
+	// Header are the headers set by the handler, e.g. the ETag of the written document
+	Header map[string][]string `json:"header,omitempty" bson:"header,omitempty"`
+	Body   []byte              `json:"body" bson:"body"`
+}
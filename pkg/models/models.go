@@ -1,19 +1,47 @@
 package models
 
 import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"encoding/base64"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"log"
 )
 
+var ErrInvalidCursor = domain_errors.InvalidArgument("invalid cursor", nil)
+
 type Pagination struct {
 	Page    int64
 	PerPage int64
+	// Cursor is an opaque position returned as NextCursor by a previous page, it takes precedence over Page
+	Cursor string
+
+	// Filled by the repository
+	Total      int64
+	HasNext    bool
+	NextCursor string
 }
 
 func (p Pagination) GetPagination() (limit int64, skip int64) {
 	return p.PerPage, (p.Page - 1) * p.PerPage
 }
 
+// EncodeCursor hide the last seen document id behind an opaque string
+func EncodeCursor(id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// DecodeCursor returns the document id a cursor points to
+func DecodeCursor(cursor string) (primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) != len(primitive.NilObjectID) {
+		return primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	var id primitive.ObjectID
+	copy(id[:], raw)
+	return id, nil
+}
+
 func GenerateObjectIDFromHex(hex string) primitive.ObjectID {
 	objectID, err := primitive.ObjectIDFromHex(hex)
 	if err != nil {
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var ErrInvalidProjection = errors.New("invalid projection")
+
+// Projection selects which fields of a model are read, by their bson names;
+// Fields and Exclude can't be used together, an empty projection reads every field
+type Projection struct {
+	Fields  []string
+	Exclude []string
+}
+
+// Includes tells if the bson field is read with this projection
+func (p Projection) Includes(field string) bool {
+
+	if len(p.Fields) > 0 {
+		return containsField(p.Fields, field) || field == "_id"
+	}
+
+	return !containsField(p.Exclude, field)
+}
+
+// NewProjection validate json field names against the model and translate them to bson names
+func NewProjection(model interface{}, fields []string, exclude []string) (Projection, error) {
+
+	if len(fields) > 0 && len(exclude) > 0 {
+		return Projection{}, domain_errors.InvalidArgument("fields and exclude can't be used together", ErrInvalidProjection)
+	}
+
+	fieldMap := FieldMap(model)
+
+	translate := func(names []string) ([]string, error) {
+		translated := make([]string, 0)
+		for _, name := range names {
+			bsonName, ok := fieldMap[strings.TrimSpace(name)]
+			if !ok {
+				return nil, domain_errors.InvalidArgument(fmt.Sprintf("unknown field %q", name), ErrInvalidProjection)
+			}
+			translated = append(translated, bsonName)
+		}
+		return translated, nil
+	}
+
+	projection := Projection{}
+	var err error
+	if projection.Fields, err = translate(fields); err != nil {
+		return Projection{}, err
+	}
+	if projection.Exclude, err = translate(exclude); err != nil {
+		return Projection{}, err
+	}
+
+	return projection, nil
+}
+
+// FieldMap maps the json name of every stored field of a model to its bson name
+func FieldMap(model interface{}) map[string]string {
+
+	fieldMap := make(map[string]string)
+
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		bsonName := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if jsonName == "" || jsonName == "-" || bsonName == "" || bsonName == "-" {
+			continue
+		}
+
+		fieldMap[jsonName] = bsonName
+	}
+
+	return fieldMap
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
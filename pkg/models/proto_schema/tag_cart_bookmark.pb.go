@@ -0,0 +1,246 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.4
+// source: tag_cart_bookmark.proto
+
+package __
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PurgeCourseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CourseID string `protobuf:"bytes,1,opt,name=courseID,proto3" json:"courseID,omitempty"`
+}
+
+func (x *PurgeCourseRequest) Reset() {
+	*x = PurgeCourseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tag_cart_bookmark_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PurgeCourseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeCourseRequest) ProtoMessage() {}
+
+func (x *PurgeCourseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tag_cart_bookmark_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeCourseRequest.ProtoReflect.Descriptor instead.
+func (*PurgeCourseRequest) Descriptor() ([]byte, []int) {
+	return file_tag_cart_bookmark_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PurgeCourseRequest) GetCourseID() string {
+	if x != nil {
+		return x.CourseID
+	}
+	return ""
+}
+
+type PurgeCourseResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CourseID          string `protobuf:"bytes,1,opt,name=courseID,proto3" json:"courseID,omitempty"`
+	BookmarksModified int64  `protobuf:"varint,2,opt,name=bookmarksModified,proto3" json:"bookmarksModified,omitempty"`
+	CartsModified     int64  `protobuf:"varint,3,opt,name=cartsModified,proto3" json:"cartsModified,omitempty"`
+	NotificationsSent int64  `protobuf:"varint,4,opt,name=notificationsSent,proto3" json:"notificationsSent,omitempty"`
+}
+
+func (x *PurgeCourseResult) Reset() {
+	*x = PurgeCourseResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tag_cart_bookmark_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PurgeCourseResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeCourseResult) ProtoMessage() {}
+
+func (x *PurgeCourseResult) ProtoReflect() protoreflect.Message {
+	mi := &file_tag_cart_bookmark_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeCourseResult.ProtoReflect.Descriptor instead.
+func (*PurgeCourseResult) Descriptor() ([]byte, []int) {
+	return file_tag_cart_bookmark_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PurgeCourseResult) GetCourseID() string {
+	if x != nil {
+		return x.CourseID
+	}
+	return ""
+}
+
+func (x *PurgeCourseResult) GetBookmarksModified() int64 {
+	if x != nil {
+		return x.BookmarksModified
+	}
+	return 0
+}
+
+func (x *PurgeCourseResult) GetCartsModified() int64 {
+	if x != nil {
+		return x.CartsModified
+	}
+	return 0
+}
+
+func (x *PurgeCourseResult) GetNotificationsSent() int64 {
+	if x != nil {
+		return x.NotificationsSent
+	}
+	return 0
+}
+
+var File_tag_cart_bookmark_proto protoreflect.FileDescriptor
+
+var file_tag_cart_bookmark_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x74, 0x61, 0x67, 0x5f, 0x63, 0x61, 0x72, 0x74, 0x5f, 0x62, 0x6f, 0x6f, 0x6b, 0x6d,
+	0x61, 0x72, 0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x22, 0x30, 0x0a, 0x12, 0x50, 0x75, 0x72, 0x67, 0x65, 0x43, 0x6f, 0x75, 0x72, 0x73, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x75, 0x72, 0x73, 0x65,
+	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6f, 0x75, 0x72, 0x73, 0x65,
+	0x49, 0x44, 0x22, 0xb1, 0x01, 0x0a, 0x11, 0x50, 0x75, 0x72, 0x67, 0x65, 0x43, 0x6f, 0x75, 0x72,
+	0x73, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x75, 0x72,
+	0x73, 0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6f, 0x75, 0x72,
+	0x73, 0x65, 0x49, 0x44, 0x12, 0x2c, 0x0a, 0x11, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b,
+	0x73, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x11, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x73, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69,
+	0x65, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x63, 0x61, 0x72, 0x74, 0x73, 0x4d, 0x6f, 0x64, 0x69, 0x66,
+	0x69, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x63, 0x61, 0x72, 0x74, 0x73,
+	0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x12, 0x2c, 0x0a, 0x11, 0x6e, 0x6f, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x53, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x11, 0x6e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x53, 0x65, 0x6e, 0x74, 0x32, 0x5c, 0x0a, 0x16, 0x54, 0x61, 0x67, 0x43, 0x61, 0x72,
+	0x74, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x42, 0x0a, 0x0b, 0x50, 0x75, 0x72, 0x67, 0x65, 0x43, 0x6f, 0x75, 0x72, 0x73, 0x65, 0x12,
+	0x19, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x50, 0x75, 0x72, 0x67, 0x65, 0x43, 0x6f, 0x75,
+	0x72, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6d, 0x6f, 0x64,
+	0x65, 0x6c, 0x2e, 0x50, 0x75, 0x72, 0x67, 0x65, 0x43, 0x6f, 0x75, 0x72, 0x73, 0x65, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x42, 0x03, 0x5a, 0x01, 0x2e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_tag_cart_bookmark_proto_rawDescOnce sync.Once
+	file_tag_cart_bookmark_proto_rawDescData = file_tag_cart_bookmark_proto_rawDesc
+)
+
+func file_tag_cart_bookmark_proto_rawDescGZIP() []byte {
+	file_tag_cart_bookmark_proto_rawDescOnce.Do(func() {
+		file_tag_cart_bookmark_proto_rawDescData = protoimpl.X.CompressGZIP(file_tag_cart_bookmark_proto_rawDescData)
+	})
+	return file_tag_cart_bookmark_proto_rawDescData
+}
+
+var file_tag_cart_bookmark_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_tag_cart_bookmark_proto_goTypes = []interface{}{
+	(*PurgeCourseRequest)(nil), // 0: model.PurgeCourseRequest
+	(*PurgeCourseResult)(nil),  // 1: model.PurgeCourseResult
+}
+var file_tag_cart_bookmark_proto_depIdxs = []int32{
+	0, // 0: model.TagCartBookmarkService.PurgeCourse:input_type -> model.PurgeCourseRequest
+	1, // 1: model.TagCartBookmarkService.PurgeCourse:output_type -> model.PurgeCourseResult
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_tag_cart_bookmark_proto_init() }
+func file_tag_cart_bookmark_proto_init() {
+	if File_tag_cart_bookmark_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_tag_cart_bookmark_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PurgeCourseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tag_cart_bookmark_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PurgeCourseResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_tag_cart_bookmark_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tag_cart_bookmark_proto_goTypes,
+		DependencyIndexes: file_tag_cart_bookmark_proto_depIdxs,
+		MessageInfos:      file_tag_cart_bookmark_proto_msgTypes,
+	}.Build()
+	File_tag_cart_bookmark_proto = out.File
+	file_tag_cart_bookmark_proto_rawDesc = nil
+	file_tag_cart_bookmark_proto_goTypes = nil
+	file_tag_cart_bookmark_proto_depIdxs = nil
+}
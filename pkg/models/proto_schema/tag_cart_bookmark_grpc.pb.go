@@ -0,0 +1,106 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.4
+// source: tag_cart_bookmark.proto
+
+package __
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// TagCartBookmarkServiceClient is the client API for TagCartBookmarkService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TagCartBookmarkServiceClient interface {
+	PurgeCourse(ctx context.Context, in *PurgeCourseRequest, opts ...grpc.CallOption) (*PurgeCourseResult, error)
+}
+
+type tagCartBookmarkServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTagCartBookmarkServiceClient(cc grpc.ClientConnInterface) TagCartBookmarkServiceClient {
+	return &tagCartBookmarkServiceClient{cc}
+}
+
+func (c *tagCartBookmarkServiceClient) PurgeCourse(ctx context.Context, in *PurgeCourseRequest, opts ...grpc.CallOption) (*PurgeCourseResult, error) {
+	out := new(PurgeCourseResult)
+	err := c.cc.Invoke(ctx, "/model.TagCartBookmarkService/PurgeCourse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TagCartBookmarkServiceServer is the server API for TagCartBookmarkService service.
+// All implementations must embed UnimplementedTagCartBookmarkServiceServer
+// for forward compatibility
+type TagCartBookmarkServiceServer interface {
+	PurgeCourse(context.Context, *PurgeCourseRequest) (*PurgeCourseResult, error)
+	mustEmbedUnimplementedTagCartBookmarkServiceServer()
+}
+
+// UnimplementedTagCartBookmarkServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTagCartBookmarkServiceServer struct {
+}
+
+func (UnimplementedTagCartBookmarkServiceServer) PurgeCourse(context.Context, *PurgeCourseRequest) (*PurgeCourseResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeCourse not implemented")
+}
+func (UnimplementedTagCartBookmarkServiceServer) mustEmbedUnimplementedTagCartBookmarkServiceServer() {
+}
+
+// UnsafeTagCartBookmarkServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TagCartBookmarkServiceServer will
+// result in compilation errors.
+type UnsafeTagCartBookmarkServiceServer interface {
+	mustEmbedUnimplementedTagCartBookmarkServiceServer()
+}
+
+func RegisterTagCartBookmarkServiceServer(s grpc.ServiceRegistrar, srv TagCartBookmarkServiceServer) {
+	s.RegisterService(&TagCartBookmarkService_ServiceDesc, srv)
+}
+
+func _TagCartBookmarkService_PurgeCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeCourseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagCartBookmarkServiceServer).PurgeCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/model.TagCartBookmarkService/PurgeCourse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagCartBookmarkServiceServer).PurgeCourse(ctx, req.(*PurgeCourseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TagCartBookmarkService_ServiceDesc is the grpc.ServiceDesc for TagCartBookmarkService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TagCartBookmarkService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "model.TagCartBookmarkService",
+	HandlerType: (*TagCartBookmarkServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PurgeCourse",
+			Handler:    _TagCartBookmarkService_PurgeCourse_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "tag_cart_bookmark.proto",
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// CoursePurge summarizes how many documents were affected when a course is removed upstream
+type CoursePurge struct {
+	CourseID          primitive.ObjectID `json:"course_id"`
+	BookmarksModified int64              `json:"bookmarks_modified"`
+	CartsModified     int64              `json:"carts_modified"`
+	NotificationsSent int64              `json:"notifications_sent"`
+	PurgedAt          time.Time          `json:"purged_at"`
+}
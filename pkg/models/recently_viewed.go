@@ -0,0 +1,20 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// RecentlyViewed lists the last courses a user viewed, most recent first and each course once
+type RecentlyViewed struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    string             `json:"user_id" bson:"user_id"`
+	Courses   []ViewedCourse     `json:"courses" bson:"courses"`
+	UpdatedAt *time.Time         `json:"updated_at,omitempty" bson:"updated_at"`
+}
+
+type ViewedCourse struct {
+	ID       primitive.ObjectID `json:"id" bson:"id"`
+	Name     string             `json:"name,omitempty" bson:"-"`
+	ViewedAt time.Time          `json:"viewed_at" bson:"viewed_at"`
+}
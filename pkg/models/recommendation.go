@@ -0,0 +1,24 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Recommendation caches the recommended courses of a user until 'ExpiresAt', best first
+type Recommendation struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID    string              `json:"user_id" bson:"user_id"`
+	Courses   []RecommendedCourse `json:"courses" bson:"courses"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time           `json:"expires_at" bson:"expires_at"`
+}
+
+// RecommendedCourse is scored by the bookmarks it shares with the user's courses, boosted by the tags it shares with them
+type RecommendedCourse struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	Name          string             `json:"name,omitempty" bson:"-"`
+	Score         float64            `json:"score" bson:"score"`
+	CoOccurrences int64              `json:"co_occurrences" bson:"co_occurrences"`
+	SharedTags    int64              `json:"shared_tags" bson:"shared_tags"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatisticSourceBookmarks = "bookmarks"
+	StatisticSourceCarts     = "carts"
+)
+
+// CourseStatistic holds how many users have a course bookmarked and how many have it in their cart
+type CourseStatistic struct {
+	CourseID  primitive.ObjectID `json:"course_id" bson:"_id"`
+	Name      string             `json:"name,omitempty" bson:"-"`
+	Bookmarks int64              `json:"bookmarks" bson:"bookmarks"`
+	Carts     int64              `json:"carts" bson:"carts"`
+}
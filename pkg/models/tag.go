@@ -0,0 +1,49 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Tag is a catalogue tag, tags form a tree through their 'parent'
+type Tag struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id"`
+	Name string             `json:"name" bson:"name"`
+	// SearchName is the name without case nor accents, tags are searched by its prefix
+	SearchName string               `json:"-" bson:"search_name,omitempty"`
+	Parent     *primitive.ObjectID  `json:"parent,omitempty" bson:"parent,omitempty"`
+	Courses    []primitive.ObjectID `json:"courses,omitempty" bson:"courses"`
+	Synonyms   []string             `json:"synonyms,omitempty" bson:"-"`
+	UpdatedAt  *time.Time           `json:"updated_at,omitempty" bson:"updated_at"`
+	CreatedAt  *time.Time           `json:"created_at,omitempty" bson:"created_at"`
+	DeletedAt  *time.Time           `json:"deleted_at,omitempty" bson:"deleted_at"`
+}
+
+// TagSynonym maps another spelling of a tag to the tag, synonyms are stored lower cased
+type TagSynonym struct {
+	ID        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	Synonym   string             `json:"synonym" bson:"synonym"`
+	TagID     primitive.ObjectID `json:"tag_id" bson:"tag_id"`
+	CreatedAt *time.Time         `json:"created_at,omitempty" bson:"created_at"`
+}
+
+// TagSuggestion is a tag matching a search, with the number of courses carrying it
+type TagSuggestion struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	CourseCount int64              `json:"course_count" bson:"course_count"`
+}
+
+// TagSearchName fold the case and the accents of a tag name, "Élixir" and "elixir" search the same
+func TagSearchName(name string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), name)
+	if err != nil {
+		folded = name
+	}
+	return strings.ToLower(strings.TrimSpace(folded))
+}
@@ -0,0 +1,30 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// TagAssignment is a line of a tag import, the course gets the tag
+type TagAssignment struct {
+	CourseID string `json:"course_id"`
+	Tag      string `json:"tag"`
+}
+
+// TagImportReport reports every line of a tag import, a line is either applied or failed with its error
+type TagImportReport struct {
+	Applied int             `json:"applied"`
+	Failed  int             `json:"failed"`
+	Lines   []TagImportLine `json:"lines"`
+}
+
+type TagImportLine struct {
+	Line     int    `json:"line"`
+	CourseID string `json:"course_id,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Applied  bool   `json:"applied"`
+	Error    string `json:"error,omitempty"`
+}
+
+// TagCourses are the courses attached to a tag by a single write
+type TagCourses struct {
+	Name    string
+	Courses []primitive.ObjectID
+}
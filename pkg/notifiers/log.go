@@ -0,0 +1,20 @@
+package notifiers
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"context"
+	"log"
+)
+
+// LogNotifier only writes notifications to the service log,
+// use it until a real delivery channel (mail, push) is plugged in
+type LogNotifier struct{}
+
+func (n LogNotifier) NotifyCoursePurged(ctx context.Context, userID string, courseID string, source string) error {
+	log.Println("NOTIFIER: course", courseID, "has been removed from the", source, "of user", userID)
+	return nil
+}
+
+func ConstructLogNotifier() contracts.Notifier {
+	return &LogNotifier{}
+}
@@ -0,0 +1,537 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strconv"
+	"time"
+)
+
+// addCoursesStatement builds an update pipeline which appends courses to the embedded 'courses' array;
+// ids that are already attached are skipped, so a course keeps the 'added_at' of its first insertion.
+// Pipelines have no $setOnInsert, 'created_at' keeps its value and is only set when the document is inserted,
+// 'updated_at' and 'version' only move when a course is appended so an add without new courses doesn't modify the document
+func addCoursesStatement(courses []models.Course, addedAt time.Time) mongo.Pipeline {
+
+	items := make(bson.A, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	for _, course := range courses {
+		if seen[course.ID] {
+			continue
+		}
+		seen[course.ID] = true
+		items = append(items, courseItem(course, addedAt))
+	}
+
+	attached := bson.M{"$ifNull": bson.A{"$courses.id", bson.A{}}}
+	changed := bson.M{"$gt": bson.A{bson.M{"$size": "$added_courses"}, 0}}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"added_courses": bson.M{"$filter": bson.M{
+				"input": items,
+				"as":    "course",
+				"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$course.id", attached}}}},
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"courses": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$courses", bson.A{}}},
+				"$added_courses",
+			}},
+			"created_at": bson.M{"$ifNull": bson.A{"$created_at", addedAt}},
+			"updated_at": bson.M{"$cond": bson.A{changed, addedAt, "$updated_at"}},
+			"version":    bson.M{"$cond": bson.A{changed, nextVersion, "$version"}},
+		}}},
+		{{Key: "$unset", Value: "added_courses"}},
+	}
+}
+
+// putCourseStatement builds an update pipeline which sets the seats of a course in the embedded 'courses' array,
+// the course is appended when it isn't attached yet and keeps its 'added_at' otherwise;
+// 'updated_at' and 'version' only move when the course is appended or its seats change
+func putCourseStatement(course models.Course, putAt time.Time) mongo.Pipeline {
+
+	item := courseItem(course, putAt)
+	courses := bson.M{"$ifNull": bson.A{"$courses", bson.A{}}}
+
+	//Items written before seats existed are a single seat without recipients
+	unchanged := bson.M{"$anyElementTrue": bson.A{bson.M{"$map": bson.M{
+		"input": courses,
+		"as":    "course",
+		"in": bson.M{"$and": bson.A{
+			bson.M{"$eq": bson.A{"$$course.id", course.ID}},
+			bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$$course.quantity", 1}}, course.Seats()}},
+			bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$$course.recipients", bson.A{}}}, bson.M{"$literal": recipientsOf(course)}}},
+		}},
+	}}}}
+	changed := bson.M{"$not": bson.A{unchanged}}
+	attached := bson.M{"$in": bson.A{course.ID, bson.M{"$ifNull": bson.A{"$courses.id", bson.A{}}}}}
+
+	//Seats which aren't stored are removed from the attached course
+	seats := bson.M{"id": "$$course.id", "added_at": "$$course.added_at", "quantity": "$$REMOVE", "recipients": "$$REMOVE"}
+	if quantity, ok := item["quantity"]; ok {
+		seats["quantity"] = quantity
+	}
+	if recipients, ok := item["recipients"]; ok {
+		seats["recipients"] = recipients
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"courses": bson.M{"$cond": bson.A{
+				attached,
+				bson.M{"$map": bson.M{
+					"input": courses,
+					"as":    "course",
+					"in":    bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$course.id", course.ID}}, seats, "$$course"}},
+				}},
+				bson.M{"$concatArrays": bson.A{courses, bson.A{item}}},
+			}},
+			"created_at": bson.M{"$ifNull": bson.A{"$created_at", putAt}},
+			"updated_at": bson.M{"$cond": bson.A{changed, putAt, "$updated_at"}},
+			"version":    bson.M{"$cond": bson.A{changed, nextVersion, "$version"}},
+		}}},
+	}
+}
+
+// courseItem is the embedded document of a course in an update pipeline,
+// seats are only stored for items which aren't a single seat without recipients
+func courseItem(course models.Course, addedAt time.Time) bson.M {
+	item := bson.M{"id": course.ID, "added_at": addedAt}
+	if course.Seats() > 1 {
+		item["quantity"] = course.Seats()
+	}
+	if len(course.Recipients) > 0 {
+		//emails are literals, a pipeline would read a leading '$' as a field path
+		item["recipients"] = bson.M{"$literal": recipientsOf(course)}
+	}
+	return item
+}
+
+func recipientsOf(course models.Course) bson.A {
+	recipients := bson.A{}
+	for _, recipient := range course.Recipients {
+		recipients = append(recipients, recipient)
+	}
+	return recipients
+}
+
+// removeCoursesStatement builds an update pipeline which removes courses from the embedded 'courses' array,
+// 'updated_at' and 'version' only move when a course is removed
+func removeCoursesStatement(coursesID []primitive.ObjectID, removedAt time.Time) mongo.Pipeline {
+	return removeItemsStatement("courses", coursesID, removedAt)
+}
+
+// removeItemsStatement is removeCoursesStatement for any embedded array of courses
+func removeItemsStatement(field string, coursesID []primitive.ObjectID, removedAt time.Time) mongo.Pipeline {
+
+	courses := bson.M{"$ifNull": bson.A{"$" + field, bson.A{}}}
+	changed := bson.M{"$lt": bson.A{bson.M{"$size": "$remaining_courses"}, bson.M{"$size": courses}}}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"remaining_courses": bson.M{"$filter": bson.M{
+				"input": courses,
+				"as":    "course",
+				"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$course.id", coursesID}}}},
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			field:        bson.M{"$cond": bson.A{changed, "$remaining_courses", "$" + field}},
+			"updated_at": bson.M{"$cond": bson.A{changed, removedAt, "$updated_at"}},
+			"version":    bson.M{"$cond": bson.A{changed, nextVersion, "$version"}},
+		}}},
+		{{Key: "$unset", Value: "remaining_courses"}},
+	}
+}
+
+// moveCourseStatement builds an update pipeline which moves a course from the embedded 'from' array to the 'to' array,
+// the course keeps its seats and 'added_at'; a course already in 'to' is left as it is
+func moveCourseStatement(courseID primitive.ObjectID, from string, to string, movedAt time.Time) mongo.Pipeline {
+
+	source := bson.M{"$ifNull": bson.A{"$" + from, bson.A{}}}
+	target := bson.M{"$ifNull": bson.A{"$" + to, bson.A{}}}
+	changed := bson.M{"$gt": bson.A{bson.M{"$size": "$moved_courses"}, 0}}
+
+	others := func(courses bson.M) bson.M {
+		return bson.M{"$filter": bson.M{"input": courses, "as": "course", "cond": bson.M{"$ne": bson.A{"$$course.id", courseID}}}}
+	}
+
+	//an unchanged array keeps its value, a missing array isn't created
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"moved_courses": bson.M{"$filter": bson.M{"input": source, "as": "course", "cond": bson.M{"$eq": bson.A{"$$course.id", courseID}}}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			from:         bson.M{"$cond": bson.A{changed, others(source), "$" + from}},
+			to:           bson.M{"$cond": bson.A{changed, bson.M{"$concatArrays": bson.A{others(target), "$moved_courses"}}, "$" + to}},
+			"updated_at": bson.M{"$cond": bson.A{changed, movedAt, "$updated_at"}},
+			"version":    bson.M{"$cond": bson.A{changed, nextVersion, "$version"}},
+		}}},
+		{{Key: "$unset", Value: "moved_courses"}},
+	}
+}
+
+// moveCourse move a course between two embedded arrays of the document owned by the user;
+// the filter requires the course in either array, so moving it twice succeeds, and keeps 'to' within 'maxItems'
+func moveCourse(ctx context.Context, collection *mongo.Collection, document string, userID string, courseID primitive.ObjectID, from string, to string, version int64, maxItems int64) (int64, error) {
+
+	attached := func(field string) bson.M {
+		return bson.M{"$in": bson.A{courseID, bson.M{"$ifNull": bson.A{"$" + field + ".id", bson.A{}}}}}
+	}
+	items := bson.M{"$size": bson.M{"$ifNull": bson.A{"$" + to, bson.A{}}}}
+
+	//1. The course is in one of the arrays, and 'to' has room for it unless it is there already
+	filter := ownerFilter(userID, version)
+	filter = append(filter, bson.E{Key: "$expr", Value: bson.M{"$and": bson.A{
+		bson.M{"$or": bson.A{attached(from), attached(to)}},
+		bson.M{"$or": bson.A{attached(to), bson.M{"$lt": bson.A{items, maxItems}}}},
+	}}})
+
+	written, matched, err := updateOwned(ctx, collection, filter, moveCourseStatement(courseID, from, to, time.Now()), false)
+	if err != nil {
+		return 0, err
+	}
+	if matched {
+		return written.Version, nil
+	}
+
+	//2. Tell why the document wasn't matched
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$project", Value: bson.M{
+			"version":  1,
+			"attached": bson.M{"$or": bson.A{attached(from), attached(to)}},
+			"items":    items,
+		}}},
+	}
+
+	records, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	states := make([]struct {
+		Version  int64 `bson:"version"`
+		Attached bool  `bson:"attached"`
+		Items    int64 `bson:"items"`
+	}, 0)
+	if err = records.All(ctx, &states); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case len(states) == 0:
+		return 0, unmatchedError(document, version)
+	case version > 0 && states[0].Version != version:
+		return 0, unmatchedError(document, version)
+	case !states[0].Attached:
+		return 0, domain_errors.NotFound(fmt.Sprintf("course %s isn't in the %s", courseID.Hex(), document), mongo.ErrNoDocuments)
+	default:
+		return 0, domain_errors.QuotaExceeded(fmt.Sprintf("%s can hold at most %d courses, it has %d", to, maxItems, states[0].Items), states[0].Items, maxItems)
+	}
+}
+
+// explainMissingCourse read the document owned by the user to tell why an update of one of its courses wasn't matched
+func explainMissingCourse(ctx context.Context, collection *mongo.Collection, document string, userID string, courseID primitive.ObjectID, version int64) error {
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$project", Value: bson.M{
+			"version":  1,
+			"attached": bson.M{"$in": bson.A{courseID, bson.M{"$ifNull": bson.A{"$courses.id", bson.A{}}}}},
+		}}},
+	}
+
+	records, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	states := make([]struct {
+		Version  int64 `bson:"version"`
+		Attached bool  `bson:"attached"`
+	}, 0)
+	if err = records.All(ctx, &states); err != nil {
+		return err
+	}
+
+	switch {
+	case len(states) == 0:
+		return unmatchedError(document, version)
+	case version > 0 && states[0].Version != version:
+		return unmatchedError(document, version)
+	case !states[0].Attached:
+		return domain_errors.NotFound(fmt.Sprintf("course %s isn't in the %s", courseID.Hex(), document), mongo.ErrNoDocuments)
+	default:
+		return unmatchedError(document, version)
+	}
+}
+
+// nextVersion documents written before versions existed start from zero
+var nextVersion = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}}
+
+// ownerFilter select the document owned by the user, a non zero 'version' also requires the document to be at that version
+func ownerFilter(userID string, version int64) bson.D {
+	filter := bson.D{{Key: "user_id", Value: userID}}
+	if version > 0 {
+		filter = append(filter, bson.E{Key: "version", Value: version})
+	}
+	return filter
+}
+
+// unmatchedError an update which matched nothing is a missing document, or a stale version when one was expected
+func unmatchedError(document string, version int64) error {
+	if version > 0 {
+		return domain_errors.PreconditionFailed(document+" has changed since version "+strconv.FormatInt(version, 10), nil)
+	}
+	return domain_errors.NotFound(document+" not found", mongo.ErrNoDocuments)
+}
+
+// writtenDocument is what an update reads back of the document it left
+type writtenDocument struct {
+	Version   int64     `bson:"version"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// updateOwned run an update of the document selected by 'filter' and read back the version it left the document at,
+// so the version answered is the one of this write; 'matched' is false when no document was updated nor inserted
+func updateOwned(ctx context.Context, collection *mongo.Collection, filter interface{}, statement interface{}, upsert bool) (written writtenDocument, matched bool, err error) {
+
+	opts := options.FindOneAndUpdate().
+		SetUpsert(upsert).
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"version": 1, "updated_at": 1})
+
+	err = collection.FindOneAndUpdate(ctx, filter, statement, opts).Decode(&written)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return writtenDocument{}, false, nil
+	}
+	if err != nil {
+		return writtenDocument{}, false, err
+	}
+
+	return written, true, nil
+}
+
+// writeCourses run an update pipeline which adds the 'added' courses to the document owned by the user, in a single update;
+// the document is inserted by the first add so concurrent first adds can't race on the unique 'user_id' index,
+// nothing is inserted when there is no course to add or when a 'version' is expected.
+// A positive 'maxItems' is enforced by the update filter, so concurrent adds can't grow the document past it;
+// the written document answers the version the add left it at
+func writeCourses(ctx context.Context, collection *mongo.Collection, document string, userID string, statement mongo.Pipeline, added []primitive.ObjectID, version int64, maxItems int64) (writtenDocument, error) {
+
+	ids := make([]primitive.ObjectID, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	for _, id := range added {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	//1. The filter only matches a document at the expected version which stays within the limit
+	filter := ownerFilter(userID, version)
+	if maxItems > 0 && len(ids) > 0 {
+		//an inserted document isn't checked by the filter
+		if int64(len(ids)) > maxItems {
+			return writtenDocument{}, quotaError(ctx, collection, document, userID, maxItems)
+		}
+		items := bson.M{"$size": bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$courses.id", bson.A{}}}, ids}}}
+		filter = append(filter, bson.E{Key: "$expr", Value: bson.M{"$lte": bson.A{items, maxItems}}})
+	}
+	upsert := len(ids) > 0 && version == 0
+
+	//2. Two upserts can both miss the document and race on the insert, the loser matches the winner's document on retry
+	written, matched, err := updateOwned(ctx, collection, filter, statement, upsert)
+	if mongo.IsDuplicateKeyError(err) {
+		written, matched, err = updateOwned(ctx, collection, filter, statement, upsert)
+	}
+
+	//3. A document which exists but isn't matched is either at another version or full, it isn't inserted again
+	unmatched := mongo.IsDuplicateKeyError(err) || (err == nil && !matched)
+	if unmatched && len(ids) > 0 {
+		return writtenDocument{}, explainUnmatched(ctx, collection, document, userID, version, maxItems)
+	}
+	if unmatched {
+		return writtenDocument{}, unmatchedError(document, version)
+	}
+	if err != nil {
+		return writtenDocument{}, err
+	}
+
+	return written, nil
+}
+
+// explainUnmatched read the document an add didn't match to tell why
+func explainUnmatched(ctx context.Context, collection *mongo.Collection, document string, userID string, version int64, maxItems int64) error {
+
+	current, found, err := documentSize(ctx, collection, userID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !found:
+		return unmatchedError(document, version)
+	case version > 0 && current.Version != version:
+		return unmatchedError(document, version)
+	case maxItems > 0:
+		return domain_errors.QuotaExceeded(fmt.Sprintf("a %s can hold at most %d courses, it has %d", document, maxItems, current.Items), current.Items, maxItems)
+	default:
+		return unmatchedError(document, version)
+	}
+}
+
+// quotaError a quota exceeded error with the current size of the document, an absent document is empty
+func quotaError(ctx context.Context, collection *mongo.Collection, document string, userID string, maxItems int64) error {
+
+	current, _, err := documentSize(ctx, collection, userID)
+	if err != nil {
+		return err
+	}
+
+	return domain_errors.QuotaExceeded(fmt.Sprintf("a %s can hold at most %d courses, it has %d", document, maxItems, current.Items), current.Items, maxItems)
+}
+
+type sizeOfDocument struct {
+	Version int64 `bson:"version"`
+	Items   int64 `bson:"items"`
+}
+
+func documentSize(ctx context.Context, collection *mongo.Collection, userID string) (size sizeOfDocument, found bool, err error) {
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$project", Value: bson.M{"version": 1, "items": bson.M{"$size": bson.M{"$ifNull": bson.A{"$courses", bson.A{}}}}}}},
+	}
+
+	records, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return sizeOfDocument{}, false, err
+	}
+
+	sizes := make([]sizeOfDocument, 0)
+	if err = records.All(ctx, &sizes); err != nil {
+		return sizeOfDocument{}, false, err
+	}
+	if len(sizes) == 0 {
+		return sizeOfDocument{}, false, nil
+	}
+
+	return sizes[0], true, nil
+}
+
+// createQuota a document is created with at most 'maxItems' courses
+func createQuota(document string, courses []models.Course, maxItems int64) error {
+	if maxItems > 0 && int64(len(courses)) > maxItems {
+		return domain_errors.QuotaExceeded(fmt.Sprintf("a %s can hold at most %d courses, %d were given", document, maxItems, len(courses)), 0, maxItems)
+	}
+	return nil
+}
+
+func courseIDs(courses []models.Course) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(courses))
+	for _, course := range courses {
+		ids = append(ids, course.ID)
+	}
+	return ids
+}
+
+// purgeCourse pull a course from every document of the collection which contains it in one of the embedded 'fields',
+// every field needs an index on its 'id'
+func purgeCourse(ctx context.Context, collection *mongo.Collection, courseID primitive.ObjectID, fields ...string) (usersID []string, modified int64, err error) {
+
+	contains := make(bson.A, 0, len(fields))
+	pull := bson.M{}
+	for _, field := range fields {
+		contains = append(contains, bson.M{field + ".id": courseID})
+		pull[field] = bson.M{"id": courseID}
+	}
+	filter := bson.M{"$or": contains}
+
+	//1. Collect the owners first, so they can be told about the removal
+	owners, err := collection.Distinct(ctx, "user_id", filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	usersID = make([]string, 0)
+	for _, owner := range owners {
+		if userID, ok := owner.(string); ok {
+			usersID = append(usersID, userID)
+		}
+	}
+
+	//2. Pull the course from every matched document, like every course write it moves 'updated_at' and 'version'
+	statement := bson.M{"$pull": pull, "$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+
+	result, err := collection.UpdateMany(ctx, filter, statement)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return usersID, result.ModifiedCount, nil
+}
+
+// fetchCourses select a page of the courses embedded in the document owned by the user;
+// courses are appended as they are added, so the array order is the added date order.
+// 'coursesID' restricts the courses to the given ids when it isn't nil, a zero 'limit' selects every course
+func fetchCourses(ctx context.Context, collection *mongo.Collection, userID string, coursesID []primitive.ObjectID, descending bool, limit int64, skip int64) (courses []models.Course, total int64, err error) {
+
+	var attached interface{} = bson.M{"$ifNull": bson.A{"$courses", bson.A{}}}
+	if coursesID != nil {
+		attached = bson.M{"$filter": bson.M{
+			"input": attached,
+			"as":    "course",
+			"cond":  bson.M{"$in": bson.A{"$$course.id", coursesID}},
+		}}
+	}
+
+	ordered := interface{}("$courses")
+	if descending {
+		ordered = bson.M{"$reverseArray": "$courses"}
+	}
+
+	page := ordered
+	if limit > 0 {
+		page = bson.M{"$slice": bson.A{ordered, skip, limit}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": nil}}},
+		{{Key: "$project", Value: bson.M{"courses": attached}}},
+		{{Key: "$project", Value: bson.M{"total": bson.M{"$size": "$courses"}, "courses": page}}},
+	}
+
+	records, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]struct {
+		Total   int64           `bson:"total"`
+		Courses []models.Course `bson:"courses"`
+	}, 0)
+	if err = records.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+
+	if len(result) == 0 {
+		return nil, 0, mongo.ErrNoDocuments
+	}
+
+	if result[0].Courses == nil {
+		return []models.Course{}, result[0].Total, nil
+	}
+
+	return result[0].Courses, result[0].Total, nil
+}
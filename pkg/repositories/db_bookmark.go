@@ -2,40 +2,64 @@ package repositories
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/models"
 	"context"
-	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"regexp"
+	"strconv"
+	"time"
 )
 
+// defaultMaxBookmarkItems keeps a bookmark far below the 16MB document limit
+const defaultMaxBookmarkItems = 1000
+
 type BookmarkDatabaseRepository struct {
 	Connection *mongo.Database
 	Collection *mongo.Collection
+	// MaxItems is the most courses a bookmark holds
+	MaxItems int64
 }
 
-func (d BookmarkDatabaseRepository) Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, err error) {
-	//Exclude fields
-	excluded := make(map[string]int)
-	for _, field := range exclude {
-		excluded[field] = 0
+func (d BookmarkDatabaseRepository) Fetch(ctx context.Context, projection models.Projection, filter models.BookmarkFilter, pagination *models.Pagination) (bookmarks []models.Bookmark, err error) {
+	statement := bookmarkFilterStatement(filter)
+
+	//Count Records, cursor doesn't narrow the total
+	pagination.Total, err = d.Collection.CountDocuments(ctx, statement)
+	if err != nil {
+		return nil, wrapError(err)
 	}
 
-	//Set options
+	//Set options, one more record is fetched to know if there is a next page;
+	//_id is always read since the next cursor is built from it
+	excludeID := !projection.Includes("_id")
+	if excludeID {
+		projection.Exclude = removeField(projection.Exclude, "_id")
+	}
 	opts := options.Find()
-	opts.SetProjection(excluded)
-	opts.SetLimit(limit)
-	opts.SetSkip(skip)
+	opts.SetProjection(projectionStatement(projection))
+	opts.SetSort(bson.D{{Key: "_id", Value: 1}})
+	opts.SetLimit(pagination.PerPage + 1)
 
-	//Fetch Records
-	filter := map[string]interface{}{"deleted_at": nil}
+	if pagination.Cursor != "" {
+		lastID, err := models.DecodeCursor(pagination.Cursor)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		statement["_id"] = bson.M{"$gt": lastID}
+	} else {
+		_, skip := pagination.GetPagination()
+		opts.SetSkip(skip)
+	}
 
-	records, err := d.Collection.Find(ctx, filter, opts)
+	//Fetch Records
+	records, err := d.Collection.Find(ctx, statement, opts)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	//Close Cursor
@@ -55,52 +79,100 @@ func (d BookmarkDatabaseRepository) Fetch(ctx context.Context, exclude []string,
 
 		err := records.Decode(&bookmark)
 		if err != nil {
-			return nil, err
+			return nil, wrapError(err)
 		}
 
 		bookmarks = append(bookmarks, bookmark)
 	}
 
+	pagination.HasNext = int64(len(bookmarks)) > pagination.PerPage
+	if pagination.HasNext {
+		bookmarks = bookmarks[:pagination.PerPage]
+		pagination.NextCursor = models.EncodeCursor(bookmarks[len(bookmarks)-1].ID)
+	}
+
+	//The id is left out of the response once the cursor is built
+	if excludeID {
+		for i := range bookmarks {
+			bookmarks[i].ID = primitive.NilObjectID
+		}
+	}
+
 	return bookmarks, nil
 
 }
 
-func (d BookmarkDatabaseRepository) FetchById(ctx context.Context, id string, exclude []string) (bookmarks models.Bookmark, err error) {
+// bookmarkFilterStatement translate a filter into a query, only the known fields of the filter are used
+func bookmarkFilterStatement(filter models.BookmarkFilter) bson.M {
+
+	statement := bson.M{"deleted_at": nil}
+
+	//Anchored and escaped, so the prefix can use the user_id index and never acts as a pattern
+	if filter.UserIDPrefix != "" {
+		statement["user_id"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.UserIDPrefix)}
+	}
+
+	if !filter.CourseID.IsZero() {
+		statement["courses.id"] = filter.CourseID
+	}
 
-	//Exclude fields
-	excluded := make(map[string]int)
-	for _, field := range exclude {
-		excluded[field] = 0
+	if dateRange := dateRangeStatement(filter.CreatedFrom, filter.CreatedTo); dateRange != nil {
+		statement["created_at"] = dateRange
 	}
 
+	if dateRange := dateRangeStatement(filter.UpdatedFrom, filter.UpdatedTo); dateRange != nil {
+		statement["updated_at"] = dateRange
+	}
+
+	//Having at least n courses means the (n-1)th element exists
+	if filter.MinCourses > 0 {
+		statement["courses."+strconv.FormatInt(filter.MinCourses-1, 10)] = bson.M{"$exists": true}
+	}
+
+	return statement
+}
+
+func dateRangeStatement(from *time.Time, to *time.Time) bson.M {
+
+	if from == nil && to == nil {
+		return nil
+	}
+
+	dateRange := bson.M{}
+	if from != nil {
+		dateRange["$gte"] = *from
+	}
+	if to != nil {
+		dateRange["$lt"] = *to
+	}
+
+	return dateRange
+}
+
+func (d BookmarkDatabaseRepository) FetchById(ctx context.Context, id string, projection models.Projection) (bookmarks models.Bookmark, err error) {
+
 	//Set options
-	opts := options.FindOne().SetProjection(excluded)
+	opts := options.FindOne().SetProjection(projectionStatement(projection))
 
 	var bookmark models.Bookmark
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return bookmark, err
+		return bookmark, wrapError(err)
 	}
 
 	filter := map[string]interface{}{"_id": objectID, "deleted_at": nil}
 	err = d.Collection.FindOne(ctx, filter, opts).Decode(&bookmark)
 
 	if err != nil {
-		return bookmark, err
+		return bookmark, wrapError(err)
 	}
 
 	return bookmark, nil
 }
 
-func (d BookmarkDatabaseRepository) FetchByUserId(ctx context.Context, userId string, exclude []string) (bookmarks models.Bookmark, err error) {
+func (d BookmarkDatabaseRepository) FetchByUserId(ctx context.Context, userId string, projection models.Projection) (bookmarks models.Bookmark, err error) {
 
-	//Exclude fields
-	excluded := make(map[string]int)
-	for _, field := range exclude {
-		excluded[field] = 0
-	}
-
-	opts := options.FindOne().SetProjection(excluded)
+	opts := options.FindOne().SetProjection(projectionStatement(projection))
 
 	var bookmark models.Bookmark
 
@@ -108,14 +180,93 @@ func (d BookmarkDatabaseRepository) FetchByUserId(ctx context.Context, userId st
 
 	err = d.Collection.FindOne(ctx, filter, opts).Decode(&bookmark)
 	if err != nil {
-		return bookmark, err
+		return bookmark, wrapError(err)
+	}
+
+	return bookmark, nil
+}
+
+func (d BookmarkDatabaseRepository) FetchByUserTag(ctx context.Context, userID string, tag string, projection models.Projection) (bookmark models.Bookmark, err error) {
+
+	//1. The (user_id, courses.tags) index serves the match, only the tagged courses are kept
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": nil, "courses.tags": tag}}},
+		{{Key: "$set", Value: bson.M{"courses": bson.M{"$filter": bson.M{
+			"input": "$courses",
+			"as":    "course",
+			"cond":  bson.M{"$in": bson.A{tag, bson.M{"$ifNull": bson.A{"$$course.tags", bson.A{}}}}},
+		}}}}},
+	}
+	if statement := projectionStatement(projection); len(statement) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: statement}})
+	}
+
+	records, err := d.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH BY USER TAG: ", err.Error())
+		return models.Bookmark{}, wrapError(err)
+	}
+
+	bookmarks := make([]models.Bookmark, 0)
+	if err = records.All(ctx, &bookmarks); err != nil {
+		return models.Bookmark{}, wrapError(err)
+	}
+	if len(bookmarks) > 0 {
+		return bookmarks[0], nil
+	}
+
+	//2. Without a tagged course the bookmark is answered without courses, a missing bookmark is not found
+	bookmark, err = d.FetchByUserId(ctx, userID, projection)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
+	if projection.Includes("courses") {
+		bookmark.Courses = []models.Course{}
 	}
 
 	return bookmark, nil
 }
 
+func (d BookmarkDatabaseRepository) SetCourseTags(ctx context.Context, userID string, courseID primitive.ObjectID, tags []string, version int64) (newVersion int64, err error) {
+
+	filter := append(ownerFilter(userID, version), bson.E{Key: "courses.id", Value: courseID})
+
+	statement := bson.M{"$set": bson.M{"courses.$.tags": tags, "updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+	if len(tags) == 0 {
+		statement = bson.M{"$unset": bson.M{"courses.$.tags": ""}, "$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+	}
+
+	written, matched, err := updateOwned(ctx, d.Collection, filter, statement, false)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY SET COURSE TAGS: ", err.Error())
+		return 0, wrapError(err)
+	}
+
+	if !matched {
+		log.Println("BOOKMARK REPOSITORY SET COURSE TAGS: document not matched")
+		return 0, wrapError(explainMissingCourse(ctx, d.Collection, "bookmark", userID, courseID, version))
+	}
+
+	return written.Version, nil
+}
+
+func (d BookmarkDatabaseRepository) FetchCourses(ctx context.Context, userID string, coursesID []primitive.ObjectID, descending bool, limit int64, skip int64) (courses []models.Course, total int64, err error) {
+
+	courses, total, err = fetchCourses(ctx, d.Collection, userID, coursesID, descending, limit, skip)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH COURSES: ", err.Error())
+		return nil, 0, wrapError(err)
+	}
+
+	return courses, total, nil
+}
+
 func (d BookmarkDatabaseRepository) Create(ctx context.Context, bookmark *models.Bookmark) (courseID primitive.ObjectID, err error) {
 
+	if err = createQuota("bookmark", bookmark.Courses, d.MaxItems); err != nil {
+		return primitive.NilObjectID, err
+	}
+
 	var courseId primitive.ObjectID
 
 	//	Use Transaction
@@ -147,102 +298,114 @@ func (d BookmarkDatabaseRepository) Create(ctx context.Context, bookmark *models
 	})
 
 	if err != nil {
-		return primitive.NilObjectID, err
+		return primitive.NilObjectID, wrapError(err)
 	}
 
 	return courseId, nil
 }
 
+// Update replace the bookmark when it is still at 'bookmark.Version', the stored version is incremented
 func (d BookmarkDatabaseRepository) Update(ctx context.Context, bookmark *models.Bookmark, bookmarkID string) (status bool, err error) {
 
 	objectId, err := primitive.ObjectIDFromHex(bookmarkID)
 	if err != nil {
-		return false, err
+		return false, wrapError(err)
 	}
 
-	filter := bson.D{{"_id", objectId}}
-	_, err = d.Collection.UpdateOne(ctx, filter, bson.D{{"$set", bookmark}})
+	updated := *bookmark
+	updated.ID = objectId
+	updated.Version = bookmark.Version + 1
+
+	filter := bson.D{{Key: "_id", Value: objectId}, {Key: "version", Value: bookmark.Version}}
+	result, err := d.Collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: updated}})
 	if err != nil {
-		return false, err
+		return false, wrapError(err)
 	}
-	return true, err
+
+	if result.MatchedCount == 0 {
+		exists, err := d.Collection.CountDocuments(ctx, bson.D{{Key: "_id", Value: objectId}})
+		if err != nil {
+			return false, wrapError(err)
+		}
+		if exists == 0 {
+			return false, domain_errors.NotFound("bookmark not found", mongo.ErrNoDocuments)
+		}
+		return false, domain_errors.PreconditionFailed("bookmark has changed since version "+strconv.FormatInt(bookmark.Version, 10), nil)
+	}
+
+	bookmark.Version = updated.Version
+	return true, nil
 }
 
 func (d BookmarkDatabaseRepository) Delete(ctx context.Context, bookmarkID string) (status bool, err error) {
 
 	objectID, err := primitive.ObjectIDFromHex(bookmarkID)
 	if err != nil {
-		return false, err
+		return false, wrapError(err)
 	}
 
-	_, err = d.Collection.DeleteOne(ctx, bson.D{{"_id", objectID}})
+	_, err = d.Collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: objectID}})
 	if err != nil {
-		return false, err
+		return false, wrapError(err)
 	}
 
 	return true, nil
 }
 
-func (d BookmarkDatabaseRepository) AddCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error) {
-
-	filter := bson.D{{"user_id", userID}}
+func (d BookmarkDatabaseRepository) AddCourse(ctx context.Context, userID string, coursesID []string, version int64) (newVersion int64, err error) {
 
-	coursesObjID := make([]bson.D, 0)
+	courses := make([]models.Course, 0)
 	for _, c := range coursesID {
-		coursesObjID = append(coursesObjID, bson.D{{"id", d.GenerateObjectIDFromString(c)}})
+		courses = append(courses, models.Course{ID: d.GenerateObjectIDFromString(c)})
 	}
 
-	statement := bson.M{"$addToSet": bson.M{"courses": bson.M{"$each": coursesObjID}}}
-
-	result, err := d.Collection.UpdateOne(ctx, filter, statement)
+	addedAt := time.Now()
+	written, err := writeCourses(ctx, d.Collection, "bookmark", userID, addCoursesStatement(courses, addedAt), courseIDs(courses), version, d.MaxItems)
 	if err != nil {
 		log.Println("BOOKMARK REPOSITORY ADD COURSE: ", err.Error())
-		return false, err
+		return 0, wrapError(err)
 	}
 
-	if result.MatchedCount == 0 {
-		log.Println("BOOKMARK REPOSITORY ADD COURSE: document not matched")
-		return false, errors.New("document not matched")
-
-	}
-
-	if result.ModifiedCount == 0 {
+	//'updated_at' only moves when a course is appended, an older one means every course was bookmarked already
+	if written.UpdatedAt.Before(addedAt.Truncate(time.Millisecond)) {
 		log.Println("BOOKMARK REPOSITORY ADD COURSE: document not modified")
-		return false, errors.New("document not modified")
+		return written.Version, domain_errors.Conflict("courses are already bookmarked", nil)
 	}
 
-	return true, nil
+	return written.Version, nil
 }
 
-func (d BookmarkDatabaseRepository) RevokeCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error) {
-
-	filter := bson.D{{"user_id", userID}}
+func (d BookmarkDatabaseRepository) RevokeCourse(ctx context.Context, userID string, coursesID []string, version int64) (newVersion int64, err error) {
 
 	cID := make([]primitive.ObjectID, 0)
 	for _, s := range coursesID {
 		cID = append(cID, d.GenerateObjectIDFromString(s))
 	}
 
-	statement := bson.M{"$pull": bson.M{"courses": bson.M{"id": bson.M{"$in": cID}}}}
-
-	result, err := d.Collection.UpdateOne(ctx, filter, statement)
+	written, matched, err := updateOwned(ctx, d.Collection, ownerFilter(userID, version), removeCoursesStatement(cID, time.Now()), false)
 	if err != nil {
 		log.Println("BOOKMARK REPOSITORY DELETE COURSE: ", err.Error())
-		return false, err
+		return 0, wrapError(err)
 	}
 
-	if result.MatchedCount == 0 {
+	if !matched {
 		log.Println("BOOKMARK REPOSITORY DELETE COURSE: document not matched")
-		return false, errors.New("document not matched")
+		return 0, unmatchedError("bookmark", version)
 
 	}
 
-	if result.ModifiedCount == 0 {
-		log.Println("BOOKMARK REPOSITORY DELETE COURSE: document not modified")
-		//return false, errors.New("document not modified")
+	return written.Version, nil
+}
+
+func (d BookmarkDatabaseRepository) PurgeCourse(ctx context.Context, courseID primitive.ObjectID) (usersID []string, modified int64, err error) {
+
+	usersID, modified, err = purgeCourse(ctx, d.Collection, courseID, "courses")
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY PURGE COURSE: ", err.Error())
+		return nil, 0, wrapError(err)
 	}
 
-	return true, nil
+	return usersID, modified, nil
 }
 
 func (d BookmarkDatabaseRepository) GenerateModelID() primitive.ObjectID {
@@ -257,10 +420,16 @@ func (d BookmarkDatabaseRepository) GenerateObjectIDFromString(id string) primit
 	return hex
 }
 
-func ConstructBookmarkDBRepository(conn *mongo.Database, coll *mongo.Collection) contracts.BookmarksDBRepository {
+// ConstructBookmarkDBRepository a zero 'maxItems' holds up to 1000 courses in a bookmark
+func ConstructBookmarkDBRepository(conn *mongo.Database, coll *mongo.Collection, maxItems int64) contracts.BookmarksDBRepository {
+
+	if maxItems <= 0 {
+		maxItems = defaultMaxBookmarkItems
+	}
 
 	return &BookmarkDatabaseRepository{
 		Connection: conn,
 		Collection: coll,
+		MaxItems:   maxItems,
 	}
 }
@@ -2,23 +2,33 @@ package repositories
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/models"
 	"context"
-	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"time"
 )
 
+// defaultMaxCartItems a cart is checked out at once, it holds fewer courses than a bookmark
+const defaultMaxCartItems = 100
+
 type CartDatabaseRepository struct {
 	Connection *mongo.Database
 	Collection *mongo.Collection
+	// MaxItems is the most courses a cart holds
+	MaxItems int64
 }
 
 func (c CartDatabaseRepository) Create(ctx context.Context, cart *models.Cart) (cartId primitive.ObjectID, err error) {
 
+	if err = createQuota("cart", cart.Courses, c.MaxItems); err != nil {
+		return primitive.NilObjectID, err
+	}
+
 	var courseId primitive.ObjectID
 
 	//	Use Transaction
@@ -50,50 +60,38 @@ func (c CartDatabaseRepository) Create(ctx context.Context, cart *models.Cart) (
 	})
 
 	if err != nil {
-		return primitive.NilObjectID, err
+		return primitive.NilObjectID, wrapError(err)
 	}
 
 	return courseId, nil
 }
 
-func (c CartDatabaseRepository) FetchById(ctx context.Context, id string, exclude []string) (cart models.Cart, err error) {
-
-	//1. Exclude fields
-	excluded := make(map[string]int)
-	for _, field := range exclude {
-		excluded[field] = 0
-	}
+func (c CartDatabaseRepository) FetchById(ctx context.Context, id string, projection models.Projection) (cart models.Cart, err error) {
 
-	//2. Set options
-	opts := options.FindOne().SetProjection(excluded)
+	//1. Set options
+	opts := options.FindOne().SetProjection(projectionStatement(projection))
 
-	//3. Collection result
+	//2. Collection result
 	var cartRes models.Cart
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return cartRes, err
+		return cartRes, wrapError(err)
 	}
 
-	//4. Setup Filter
+	//3. Setup Filter
 	filter := map[string]interface{}{"_id": objectID, "deleted_at": nil}
 	err = c.Collection.FindOne(ctx, filter, opts).Decode(&cartRes)
 
 	if err != nil {
-		return cartRes, err
+		return cartRes, wrapError(err)
 	}
 
 	return cartRes, nil
 }
 
-func (c CartDatabaseRepository) FetchByUserId(ctx context.Context, userID string, exclude []string) (cart models.Cart, err error) {
-
-	//Exclude fields
-	excluded := make(map[string]int)
-	for _, field := range exclude {
-		excluded[field] = 0
-	}
+func (c CartDatabaseRepository) FetchByUserId(ctx context.Context, userID string, projection models.Projection) (cart models.Cart, err error) {
 
-	opts := options.FindOne().SetProjection(excluded)
+	opts := options.FindOne().SetProjection(projectionStatement(projection))
 
 	var bookmark models.Cart
 
@@ -101,106 +99,161 @@ func (c CartDatabaseRepository) FetchByUserId(ctx context.Context, userID string
 
 	err = c.Collection.FindOne(ctx, filter, opts).Decode(&bookmark)
 	if err != nil {
-		return bookmark, err
+		return bookmark, wrapError(err)
 	}
 
 	return bookmark, nil
 
 }
 
-func (c CartDatabaseRepository) AddCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error) {
+func (c CartDatabaseRepository) FetchCourses(ctx context.Context, userID string, coursesID []primitive.ObjectID, descending bool, limit int64, skip int64) (courses []models.Course, total int64, err error) {
 
-	//1. Filter by id
-	filter := bson.D{{"user_id", userID}}
-
-	//2. Convert id string to ObjectID
-	coursesObjID := make([]bson.D, 0)
-	for _, c := range coursesID {
-		cID, err := primitive.ObjectIDFromHex(c)
-		if err == nil {
-			coursesObjID = append(coursesObjID, bson.D{{"id", cID}})
-		}
-		//coursesObjID = append(coursesObjID, bson.D{{"id", c.ID}})
+	courses, total, err = fetchCourses(ctx, c.Collection, userID, coursesID, descending, limit, skip)
+	if err != nil {
+		log.Println("CART REPOSITORY FETCH COURSES: ", err.Error())
+		return nil, 0, wrapError(err)
 	}
 
-	//3. Prepare statement
-	statement := bson.M{"$addToSet": bson.M{"courses": bson.M{"$each": coursesObjID}}}
+	return courses, total, nil
+}
+
+func (c CartDatabaseRepository) AddCourse(ctx context.Context, userID string, courses []models.Course, version int64) (newVersion int64, err error) {
 
-	//4. Update data
-	result, err := c.Collection.UpdateOne(ctx, filter, statement)
+	//Upsert the cart, courses already in the cart are skipped and courses saved for later are moved back;
+	//without a course or with an expected version nothing is inserted, the cart must exist
+	statement := append(addCoursesStatement(courses, time.Now()), unsaveStage(courseIDs(courses)))
+	written, err := writeCourses(ctx, c.Collection, "cart", userID, statement, courseIDs(courses), version, c.MaxItems)
 	if err != nil {
-		log.Println("BOOKMARK REPOSITORY ADD COURSE: ", err.Error())
-		return false, err
+		log.Println("CART REPOSITORY ADD COURSE: ", err.Error())
+		return 0, wrapError(err)
 	}
 
-	//5. Check if document exist / matched by the filter statements
-	if result.MatchedCount == 0 {
-		log.Println("BOOKMARK REPOSITORY ADD COURSE: document not matched")
-		return false, mongo.ErrNoDocuments
-	}
+	return written.Version, nil
 
-	////6.
-	//if result.ModifiedCount == 0 {
-	//	log.Println("BOOKMARK REPOSITORY ADD COURSE: document not matched")
-	//	return false, errors.New("document not modified")
-	//}
+}
 
-	return true, nil
+func (c CartDatabaseRepository) PutCourse(ctx context.Context, userID string, course models.Course, version int64) (newVersion int64, err error) {
 
-}
+	//a course saved for later is moved back with the new seats
+	statement := append(putCourseStatement(course, time.Now()), unsaveStage([]primitive.ObjectID{course.ID}))
+	written, err := writeCourses(ctx, c.Collection, "cart", userID, statement, []primitive.ObjectID{course.ID}, version, c.MaxItems)
+	if err != nil {
+		log.Println("CART REPOSITORY PUT COURSE: ", err.Error())
+		return 0, wrapError(err)
+	}
 
-func (c CartDatabaseRepository) RevokeCourse(ctx context.Context, userID string, coursesID []string) (status bool, err error) {
+	return written.Version, nil
+}
 
-	filter := bson.D{{"user_id", userID}}
+func (c CartDatabaseRepository) RevokeCourse(ctx context.Context, userID string, coursesID []string, version int64) (newVersion int64, err error) {
 
 	cID := make([]primitive.ObjectID, 0)
 	for _, s := range coursesID {
 		cID = append(cID, models.GenerateObjectIDFromHex(s))
 	}
 
-	statement := bson.M{"$pull": bson.M{"courses": bson.M{"id": bson.M{"$in": cID}}}}
-
-	result, err := c.Collection.UpdateOne(ctx, filter, statement)
+	written, matched, err := updateOwned(ctx, c.Collection, ownerFilter(userID, version), removeCoursesStatement(cID, time.Now()), false)
 	if err != nil {
 		log.Println("BOOKMARK REPOSITORY DELETE COURSE: ", err.Error())
-		return false, err
+		return 0, wrapError(err)
 	}
 
-	if result.MatchedCount == 0 {
+	if !matched {
 		log.Println("BOOKMARK REPOSITORY DELETE COURSE: document not matched")
-		return false, mongo.ErrNoDocuments
+		return 0, unmatchedError("cart", version)
+	}
+
+	return written.Version, nil
+}
+
+func (c CartDatabaseRepository) MoveCourse(ctx context.Context, userID string, courseID primitive.ObjectID, saved bool, version int64) (newVersion int64, err error) {
+
+	from, to := "courses", "saved_courses"
+	if !saved {
+		from, to = to, from
 	}
 
-	if result.ModifiedCount == 0 {
-		log.Println("BOOKMARK REPOSITORY DELETE COURSE: document not modified")
-		//return false, errors.New("document not modified")
+	newVersion, err = moveCourse(ctx, c.Collection, "cart", userID, courseID, from, to, version, c.MaxItems)
+	if err != nil {
+		log.Println("CART REPOSITORY MOVE COURSE: ", err.Error())
+		return 0, wrapError(err)
 	}
 
-	return true, nil
+	return newVersion, nil
+}
+
+func (c CartDatabaseRepository) RevokeSavedCourse(ctx context.Context, userID string, coursesID []primitive.ObjectID, version int64) (newVersion int64, err error) {
+
+	written, matched, err := updateOwned(ctx, c.Collection, ownerFilter(userID, version), removeItemsStatement("saved_courses", coursesID, time.Now()), false)
+	if err != nil {
+		log.Println("CART REPOSITORY REVOKE SAVED COURSE: ", err.Error())
+		return 0, wrapError(err)
+	}
+
+	if !matched {
+		log.Println("CART REPOSITORY REVOKE SAVED COURSE: document not matched")
+		return 0, unmatchedError("cart", version)
+	}
+
+	return written.Version, nil
+}
+
+// unsaveStage is an update pipeline stage which removes courses from the courses saved for later,
+// the stage runs with a write to 'courses' which already moves 'version' when a saved course comes back
+func unsaveStage(coursesID []primitive.ObjectID) bson.D {
+	return bson.D{{Key: "$set", Value: bson.M{
+		"saved_courses": bson.M{"$cond": bson.A{
+			bson.M{"$isArray": "$saved_courses"},
+			bson.M{"$filter": bson.M{
+				"input": "$saved_courses",
+				"as":    "course",
+				"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$course.id", coursesID}}}},
+			}},
+			"$$REMOVE",
+		}},
+	}}}
+}
+
+func (c CartDatabaseRepository) PurgeCourse(ctx context.Context, courseID primitive.ObjectID) (usersID []string, modified int64, err error) {
+
+	usersID, modified, err = purgeCourse(ctx, c.Collection, courseID, "courses", "saved_courses")
+	if err != nil {
+		log.Println("CART REPOSITORY PURGE COURSE: ", err.Error())
+		return nil, 0, wrapError(err)
+	}
+
+	return usersID, modified, nil
 }
 
 func (c CartDatabaseRepository) Delete(ctx context.Context, cartID string) (status bool, err error) {
 
 	objectID, err := primitive.ObjectIDFromHex(cartID)
 	if err != nil {
-		return false, err
+		return false, wrapError(err)
 	}
 
-	result, err := c.Collection.DeleteOne(ctx, bson.D{{"_id", objectID}})
+	result, err := c.Collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: objectID}})
 	if err != nil {
-		return false, err
+		return false, wrapError(err)
 	}
 
 	if result.DeletedCount == 0 {
-		return false, errors.New("mongo: no documents in result")
+		return false, domain_errors.NotFound("cart not found", mongo.ErrNoDocuments)
 	}
 
 	return true, nil
 }
 
-func ConstructCartDBRepository(conn *mongo.Database, coll *mongo.Collection) contracts.CartDBRepository {
+// ConstructCartDBRepository a zero 'maxItems' holds up to 100 courses in a cart
+func ConstructCartDBRepository(conn *mongo.Database, coll *mongo.Collection, maxItems int64) contracts.CartDBRepository {
+
+	if maxItems <= 0 {
+		maxItems = defaultMaxCartItems
+	}
+
 	return &CartDatabaseRepository{
 		Connection: conn,
 		Collection: coll,
+		MaxItems:   maxItems,
 	}
 }
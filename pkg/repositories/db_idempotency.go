@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+type IdempotencyDatabaseRepository struct {
+	Connection *mongo.Database
+	Collection *mongo.Collection
+}
+
+func (i IdempotencyDatabaseRepository) Create(ctx context.Context, record *models.IdempotencyRecord) error {
+
+	//The unique (user_id, key) index rejects a second reservation
+	_, err := i.Collection.InsertOne(ctx, record)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	return nil
+}
+
+func (i IdempotencyDatabaseRepository) FetchByKey(ctx context.Context, userID string, key string) (record models.IdempotencyRecord, err error) {
+
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "key", Value: key}}
+
+	err = i.Collection.FindOne(ctx, filter).Decode(&record)
+	if err != nil {
+		return models.IdempotencyRecord{}, wrapError(err)
+	}
+
+	return record, nil
+}
+
+func (i IdempotencyDatabaseRepository) Complete(ctx context.Context, userID string, key string, response models.IdempotentResponse) error {
+
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "key", Value: key}}
+	statement := bson.D{{Key: "$set", Value: bson.D{{Key: "response", Value: response}}}}
+
+	result, err := i.Collection.UpdateOne(ctx, filter, statement)
+	if err != nil {
+		log.Println("IDEMPOTENCY REPOSITORY COMPLETE: ", err.Error())
+		return wrapError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		log.Println("IDEMPOTENCY REPOSITORY COMPLETE: document not matched")
+	}
+
+	return nil
+}
+
+func (i IdempotencyDatabaseRepository) Delete(ctx context.Context, userID string, key string) error {
+
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "key", Value: key}}
+
+	_, err := i.Collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	return nil
+}
+
+func (i IdempotencyDatabaseRepository) FetchByUserId(ctx context.Context, userID string) (records []models.IdempotencyRecord, err error) {
+
+	cursor, err := i.Collection.Find(ctx, bson.D{{Key: "user_id", Value: userID}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		log.Println("IDEMPOTENCY REPOSITORY FETCH BY USER ID: ", err.Error())
+		return nil, wrapError(err)
+	}
+
+	records = make([]models.IdempotencyRecord, 0)
+	err = cursor.All(ctx, &records)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return records, nil
+}
+
+func ConstructIdempotencyDBRepository(conn *mongo.Database, coll *mongo.Collection) contracts.IdempotencyDBRepository {
+	return &IdempotencyDatabaseRepository{
+		Connection: conn,
+		Collection: coll,
+	}
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// defaultMaxRecentlyViewed is the length of the recently viewed rail
+const defaultMaxRecentlyViewed = 20
+
+type RecentlyViewedDatabaseRepository struct {
+	Connection *mongo.Database
+	Collection *mongo.Collection
+	// MaxItems is the most courses a recently viewed list keeps
+	MaxItems int64
+}
+
+func (r RecentlyViewedDatabaseRepository) Record(ctx context.Context, userID string, courseID primitive.ObjectID, viewedAt time.Time) error {
+
+	//1. A single pipeline update drops an earlier view of the course, puts it in front and cuts the tail,
+	//so concurrent views can't interleave; the list is inserted by the first view
+	filter := bson.D{{Key: "user_id", Value: userID}}
+	statement := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"courses": bson.M{"$slice": bson.A{
+				bson.M{"$concatArrays": bson.A{
+					bson.A{models.ViewedCourse{ID: courseID, ViewedAt: viewedAt}},
+					bson.M{"$filter": bson.M{
+						"input": bson.M{"$ifNull": bson.A{"$courses", bson.A{}}},
+						"as":    "course",
+						"cond":  bson.M{"$ne": bson.A{"$$course.id", courseID}},
+					}},
+				}},
+				r.MaxItems,
+			}},
+			"updated_at": viewedAt,
+		}}},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	//2. A concurrent first view inserts the list, the retry updates it
+	_, err := r.Collection.UpdateOne(ctx, filter, statement, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		_, err = r.Collection.UpdateOne(ctx, filter, statement, opts)
+	}
+	if err != nil {
+		log.Println("RECENTLY VIEWED REPOSITORY RECORD: ", err.Error())
+		return wrapError(err)
+	}
+
+	return nil
+}
+
+func (r RecentlyViewedDatabaseRepository) FetchByUserId(ctx context.Context, userID string, limit int64) (recentlyViewed models.RecentlyViewed, err error) {
+
+	opts := options.FindOne()
+	if limit > 0 {
+		opts.SetProjection(bson.M{"courses": bson.M{"$slice": limit}})
+	}
+
+	err = r.Collection.FindOne(ctx, bson.D{{Key: "user_id", Value: userID}}, opts).Decode(&recentlyViewed)
+	if err != nil {
+		return models.RecentlyViewed{}, wrapError(err)
+	}
+
+	return recentlyViewed, nil
+}
+
+// ConstructRecentlyViewedDBRepository a zero 'maxItems' keeps the 20 last courses
+func ConstructRecentlyViewedDBRepository(conn *mongo.Database, coll *mongo.Collection, maxItems int64) contracts.RecentlyViewedDBRepository {
+
+	if maxItems <= 0 {
+		maxItems = defaultMaxRecentlyViewed
+	}
+
+	return &RecentlyViewedDatabaseRepository{
+		Connection: conn,
+		Collection: coll,
+		MaxItems:   maxItems,
+	}
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const (
+	// sharedTagBoost is the score of a tag a course shares with the user's courses, a shared bookmark scores 1
+	sharedTagBoost = 0.5
+	// candidatesPerRecommendation is how many of the most co-occurring courses are scored with their tags per recommended course
+	candidatesPerRecommendation = 4
+)
+
+type RecommendationDatabaseRepository struct {
+	Connection      *mongo.Database
+	Bookmarks       *mongo.Collection
+	Carts           *mongo.Collection
+	Tags            *mongo.Collection
+	Recommendations *mongo.Collection
+}
+
+func (r RecommendationDatabaseRepository) Compute(ctx context.Context, userID string, limit int64) (courses []models.RecommendedCourse, err error) {
+
+	courses = make([]models.RecommendedCourse, 0)
+
+	//1. The user's bookmarked courses are the signal, a user without bookmark has no recommendation
+	bookmarked, err := r.ownedCourses(ctx, r.Bookmarks, userID, "courses")
+	if err != nil {
+		return nil, err
+	}
+	if len(bookmarked) == 0 {
+		return courses, nil
+	}
+
+	carted, err := r.ownedCourses(ctx, r.Carts, userID, "courses", "saved_courses")
+	if err != nil {
+		return nil, err
+	}
+	excluded := append(append([]primitive.ObjectID{}, bookmarked...), carted...)
+
+	//2. The tags of the user's courses boost the candidates carrying them
+	tagsID := make([]primitive.ObjectID, 0)
+	tags, err := r.Tags.Find(ctx, bson.M{"deleted_at": nil, "courses": bson.M{"$in": bookmarked}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		log.Println("RECOMMENDATION REPOSITORY COMPUTE: ", err.Error())
+		return nil, wrapError(err)
+	}
+	var tagged []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = tags.All(ctx, &tagged); err != nil {
+		return nil, wrapError(err)
+	}
+	for _, tag := range tagged {
+		tagsID = append(tagsID, tag.ID)
+	}
+
+	//3. Every other bookmark sharing courses with the user's votes for its other courses, once per shared course
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": bson.M{"$ne": userID}, "deleted_at": nil, "courses.id": bson.M{"$in": bookmarked}}}},
+		{{Key: "$project", Value: bson.M{
+			"courses": "$courses.id",
+			"shared":  bson.M{"$size": bson.M{"$setIntersection": bson.A{"$courses.id", bookmarked}}},
+		}}},
+		{{Key: "$unwind", Value: "$courses"}},
+		{{Key: "$match", Value: bson.M{"courses": bson.M{"$nin": excluded}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$courses", "co_occurrences": bson.M{"$sum": "$shared"}}}},
+		//only the most co-occurring candidates look their tags up, the tags then rank them
+		{{Key: "$sort", Value: bson.D{{Key: "co_occurrences", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit * candidatesPerRecommendation}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": r.Tags.Name(),
+			"let":  bson.M{"course": "$_id"},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"_id": bson.M{"$in": tagsID}}}},
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$in": bson.A{"$$course", bson.M{"$ifNull": bson.A{"$courses", bson.A{}}}}}}}},
+				{{Key: "$project", Value: bson.M{"_id": 1}}},
+			},
+			"as": "shared_tags",
+		}}},
+		{{Key: "$set", Value: bson.M{"shared_tags": bson.M{"$size": "$shared_tags"}}}},
+		{{Key: "$set", Value: bson.M{"score": bson.M{"$add": bson.A{"$co_occurrences", bson.M{"$multiply": bson.A{"$shared_tags", sharedTagBoost}}}}}}},
+		//course id keeps the order stable on ties
+		{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	records, err := r.Bookmarks.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Println("RECOMMENDATION REPOSITORY COMPUTE: ", err.Error())
+		return nil, wrapError(err)
+	}
+
+	if err = records.All(ctx, &courses); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return courses, nil
+}
+
+func (r RecommendationDatabaseRepository) OwnedCourses(ctx context.Context, userID string) ([]primitive.ObjectID, error) {
+
+	bookmarked, err := r.ownedCourses(ctx, r.Bookmarks, userID, "courses")
+	if err != nil {
+		return nil, err
+	}
+
+	carted, err := r.ownedCourses(ctx, r.Carts, userID, "courses", "saved_courses")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(bookmarked, carted...), nil
+}
+
+// ownedCourses list the ids of the courses embedded in the 'fields' of the user's document
+func (r RecommendationDatabaseRepository) ownedCourses(ctx context.Context, collection *mongo.Collection, userID string, fields ...string) ([]primitive.ObjectID, error) {
+
+	projection := bson.M{}
+	for _, field := range fields {
+		projection[field+".id"] = 1
+	}
+
+	var document struct {
+		Courses      []models.Course `bson:"courses"`
+		SavedCourses []models.Course `bson:"saved_courses"`
+	}
+	err := collection.FindOne(ctx, bson.M{"user_id": userID, "deleted_at": nil}, options.FindOne().SetProjection(projection)).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("RECOMMENDATION REPOSITORY OWNED COURSES: ", err.Error())
+		return nil, wrapError(err)
+	}
+
+	ids := courseIDs(append(document.Courses, document.SavedCourses...))
+	return ids, nil
+}
+
+func (r RecommendationDatabaseRepository) FetchCached(ctx context.Context, userID string) (recommendation models.Recommendation, err error) {
+
+	//the TTL index removes expired recommendations lazily, they are skipped until then
+	filter := bson.M{"user_id": userID, "expires_at": bson.M{"$gt": time.Now()}}
+
+	err = r.Recommendations.FindOne(ctx, filter).Decode(&recommendation)
+	if err != nil {
+		return models.Recommendation{}, wrapError(err)
+	}
+
+	return recommendation, nil
+}
+
+func (r RecommendationDatabaseRepository) Cache(ctx context.Context, recommendation *models.Recommendation) error {
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.Recommendations.ReplaceOne(ctx, bson.M{"user_id": recommendation.UserID}, recommendation, opts)
+	if err != nil {
+		log.Println("RECOMMENDATION REPOSITORY CACHE: ", err.Error())
+		return wrapError(err)
+	}
+
+	return nil
+}
+
+func ConstructRecommendationDBRepository(conn *mongo.Database, bookmarks *mongo.Collection, carts *mongo.Collection, tags *mongo.Collection, recommendations *mongo.Collection) contracts.RecommendationDBRepository {
+	return &RecommendationDatabaseRepository{
+		Connection:      conn,
+		Bookmarks:       bookmarks,
+		Carts:           carts,
+		Tags:            tags,
+		Recommendations: recommendations,
+	}
+}
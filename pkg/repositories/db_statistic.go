@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"time"
+)
+
+type StatisticDatabaseRepository struct {
+	Connection *mongo.Database
+	Bookmarks  *mongo.Collection
+	Carts      *mongo.Collection
+}
+
+func (s StatisticDatabaseRepository) CountByCourses(ctx context.Context, coursesID []string) (statistics []models.CourseStatistic, err error) {
+
+	//1. Convert id string to ObjectID, keeping the requested order
+	cID := make([]primitive.ObjectID, 0)
+	indexes := make(map[primitive.ObjectID]int)
+	statistics = make([]models.CourseStatistic, 0)
+	for _, c := range coursesID {
+		objectID, err := primitive.ObjectIDFromHex(c)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		if _, ok := indexes[objectID]; ok {
+			continue
+		}
+		indexes[objectID] = len(statistics)
+		cID = append(cID, objectID)
+		statistics = append(statistics, models.CourseStatistic{CourseID: objectID})
+	}
+
+	//2. Count every source, the group stage names the counter after the source
+	for _, source := range []string{models.StatisticSourceBookmarks, models.StatisticSourceCarts} {
+
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"deleted_at": nil, "courses.id": bson.M{"$in": cID}}}},
+			{{Key: "$unwind", Value: "$courses"}},
+			{{Key: "$match", Value: bson.M{"courses.id": bson.M{"$in": cID}}}},
+			{{Key: "$group", Value: bson.M{"_id": "$courses.id", source: bson.M{"$sum": 1}}}},
+		}
+
+		results, err := s.aggregate(ctx, source, pipeline)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+
+		//3. Merge counters into the requested courses
+		for _, result := range results {
+			i := indexes[result.CourseID]
+			statistics[i].Bookmarks += result.Bookmarks
+			statistics[i].Carts += result.Carts
+		}
+	}
+
+	return statistics, nil
+}
+
+func (s StatisticDatabaseRepository) TopCourses(ctx context.Context, source string, limit int64, since *time.Time, until *time.Time) (statistics []models.CourseStatistic, err error) {
+
+	//1. Courses without 'added_at' were added before it was tracked, they only count for an open window
+	window := bson.M{}
+	if since != nil {
+		window["$gte"] = *since
+	}
+	if until != nil {
+		window["$lt"] = *until
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": nil}}},
+		{{Key: "$unwind", Value: "$courses"}},
+	}
+	if len(window) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"courses.added_at": window}}})
+	}
+
+	//2. Rank by counter, course id keeps the order stable on ties
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$courses.id", source: bson.M{"$sum": 1}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: source, Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	)
+
+	return s.aggregate(ctx, source, pipeline)
+}
+
+func (s StatisticDatabaseRepository) aggregate(ctx context.Context, source string, pipeline mongo.Pipeline) (statistics []models.CourseStatistic, err error) {
+
+	var collection *mongo.Collection
+	switch source {
+	case models.StatisticSourceBookmarks:
+		collection = s.Bookmarks
+	case models.StatisticSourceCarts:
+		collection = s.Carts
+	default:
+		return nil, domain_errors.InvalidArgument("unknown statistic source "+source, nil)
+	}
+
+	records, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Println("STATISTIC REPOSITORY AGGREGATE: ", err.Error())
+		return nil, wrapError(err)
+	}
+
+	statistics = make([]models.CourseStatistic, 0)
+	if err = records.All(ctx, &statistics); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return statistics, nil
+}
+
+func ConstructStatisticDBRepository(conn *mongo.Database, bookmarks *mongo.Collection, carts *mongo.Collection) contracts.StatisticDBRepository {
+	return &StatisticDatabaseRepository{
+		Connection: conn,
+		Bookmarks:  bookmarks,
+		Carts:      carts,
+	}
+}
@@ -0,0 +1,414 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type TagDatabaseRepository struct {
+	Connection *mongo.Database
+	Collection *mongo.Collection
+	// Synonyms maps other spellings to the tags of Collection
+	Synonyms *mongo.Collection
+}
+
+func (t TagDatabaseRepository) FetchCoursesByTag(ctx context.Context, name string) (coursesID []primitive.ObjectID, err error) {
+
+	tag, err := t.resolve(ctx, name)
+	if errors.Is(err, domain_errors.ErrNotFound) {
+		return []primitive.ObjectID{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//1. Walk down the tree from the tag, deleted tags and their subtrees are left out
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": tag.ID}}},
+		{{Key: "$graphLookup", Value: bson.M{
+			"from":                    t.Collection.Name(),
+			"startWith":               "$_id",
+			"connectFromField":        "_id",
+			"connectToField":          "parent",
+			"as":                      "descendants",
+			"restrictSearchWithMatch": bson.M{"deleted_at": nil},
+		}}},
+		//2. Union the courses of the tag and its descendants
+		{{Key: "$project", Value: bson.M{
+			"courses": bson.M{"$reduce": bson.M{
+				"input":        "$descendants.courses",
+				"initialValue": bson.M{"$ifNull": bson.A{"$courses", bson.A{}}},
+				"in":           bson.M{"$setUnion": bson.A{"$$value", bson.M{"$ifNull": bson.A{"$$this", bson.A{}}}}},
+			}},
+		}}},
+	}
+
+	cursor, err := t.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Println("TAG REPOSITORY FETCH COURSES BY TAG: ", err.Error())
+		return nil, wrapError(err)
+	}
+
+	var tagged []models.Tag
+	err = cursor.All(ctx, &tagged)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	if len(tagged) == 0 || tagged[0].Courses == nil {
+		return []primitive.ObjectID{}, nil
+	}
+
+	return tagged[0].Courses, nil
+}
+
+func (t TagDatabaseRepository) FetchByName(ctx context.Context, name string) (tag models.Tag, err error) {
+
+	tag, err = t.resolve(ctx, name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	cursor, err := t.Synonyms.Find(ctx, bson.M{"tag_id": tag.ID}, options.Find().SetSort(bson.M{"synonym": 1}))
+	if err != nil {
+		log.Println("TAG REPOSITORY FETCH BY NAME: ", err.Error())
+		return models.Tag{}, wrapError(err)
+	}
+
+	var synonyms []models.TagSynonym
+	err = cursor.All(ctx, &synonyms)
+	if err != nil {
+		return models.Tag{}, wrapError(err)
+	}
+
+	for _, synonym := range synonyms {
+		tag.Synonyms = append(tag.Synonyms, synonym.Synonym)
+	}
+
+	return tag, nil
+}
+
+func (t TagDatabaseRepository) SetParent(ctx context.Context, tagID primitive.ObjectID, parent *primitive.ObjectID) error {
+
+	now := time.Now()
+	statement := bson.M{"$unset": bson.M{"parent": ""}, "$set": bson.M{"updated_at": now}}
+
+	if parent != nil {
+
+		//1. A tag under one of its descendants would be a cycle, so the new parent must not have the tag among its ancestors
+		if *parent == tagID {
+			return domain_errors.InvalidArgument("a tag can't be its own parent", nil)
+		}
+
+		err := t.checkNotAncestor(ctx, tagID, *parent)
+		if err != nil {
+			return err
+		}
+
+		statement = bson.M{"$set": bson.M{"parent": *parent, "updated_at": now}}
+	}
+
+	//2. Move the tag, its previous parent is kept to move it back
+	var previous models.Tag
+	err := t.Collection.FindOneAndUpdate(ctx, bson.M{"_id": tagID, "deleted_at": nil}, statement,
+		options.FindOneAndUpdate().SetProjection(bson.M{"parent": 1}),
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain_errors.NotFound("tag not found", nil)
+	}
+	if err != nil {
+		log.Println("TAG REPOSITORY SET PARENT: ", err.Error())
+		return wrapError(err)
+	}
+
+	if parent == nil {
+		return nil
+	}
+
+	//3. A concurrent move may have put the new parent under the tag between the check and the write,
+	//the tree is checked again and the tag moved back; of concurrent moves making a cycle, the last one checked fails
+	err = t.checkNotAncestor(ctx, tagID, *parent)
+	if err == nil {
+		return nil
+	}
+
+	revert := bson.M{"$unset": bson.M{"parent": ""}}
+	if previous.Parent != nil {
+		revert = bson.M{"$set": bson.M{"parent": *previous.Parent}}
+	}
+	_, revertErr := t.Collection.UpdateOne(ctx, bson.M{"_id": tagID, "parent": *parent, "updated_at": now}, revert)
+	if revertErr != nil {
+		log.Println("TAG REPOSITORY SET PARENT: revert: ", revertErr.Error())
+		return wrapError(revertErr)
+	}
+
+	return err
+}
+
+// checkNotAncestor fails when the tag is among the ancestors of 'parent'
+func (t TagDatabaseRepository) checkNotAncestor(ctx context.Context, tagID primitive.ObjectID, parent primitive.ObjectID) error {
+
+	ancestors, err := t.ancestors(ctx, parent)
+	if err != nil {
+		return err
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor == tagID {
+			return domain_errors.InvalidArgument("a tag can't be moved under one of its descendants", nil)
+		}
+	}
+
+	return nil
+}
+
+func (t TagDatabaseRepository) AddSynonym(ctx context.Context, tagID primitive.ObjectID, synonym string) error {
+
+	synonym = strings.ToLower(strings.TrimSpace(synonym))
+
+	//1. A synonym is resolved after the names, one which names a tag would never be reached;
+	//names are compared folded, through the search_name index
+	named, err := t.Collection.CountDocuments(ctx, bson.M{"search_name": models.TagSearchName(synonym), "deleted_at": nil})
+	if err != nil {
+		log.Println("TAG REPOSITORY ADD SYNONYM: ", err.Error())
+		return wrapError(err)
+	}
+	if named > 0 {
+		return domain_errors.Conflict("synonym "+synonym+" already names a tag", nil)
+	}
+
+	//2. The unique index keeps a synonym to a single tag, adding it again to the same tag is a no-op
+	now := time.Now()
+	_, err = t.Synonyms.UpdateOne(ctx,
+		bson.M{"synonym": synonym, "tag_id": tagID},
+		bson.M{"$setOnInsert": models.TagSynonym{Synonym: synonym, TagID: tagID, CreatedAt: &now}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain_errors.Conflict("synonym "+synonym+" belongs to another tag", err)
+	}
+	if err != nil {
+		log.Println("TAG REPOSITORY ADD SYNONYM: ", err.Error())
+		return wrapError(err)
+	}
+
+	return nil
+}
+
+func (t TagDatabaseRepository) RemoveSynonym(ctx context.Context, tagID primitive.ObjectID, synonym string) error {
+
+	result, err := t.Synonyms.DeleteOne(ctx, bson.M{"synonym": strings.ToLower(strings.TrimSpace(synonym)), "tag_id": tagID})
+	if err != nil {
+		log.Println("TAG REPOSITORY REMOVE SYNONYM: ", err.Error())
+		return wrapError(err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain_errors.NotFound("synonym not found", nil)
+	}
+
+	return nil
+}
+
+func (t TagDatabaseRepository) AttachCourse(ctx context.Context, name string, courseID primitive.ObjectID) (tag models.Tag, err error) {
+
+	//1. A synonym attaches to its tag, an unknown name is inserted as a root tag
+	filter := bson.M{"name": name, "deleted_at": nil}
+	resolved, err := t.resolve(ctx, name)
+	switch {
+	case err == nil:
+		filter = bson.M{"_id": resolved.ID, "deleted_at": nil}
+	case !errors.Is(err, domain_errors.ErrNotFound):
+		return models.Tag{}, err
+	}
+
+	now := time.Now()
+	statement := bson.M{
+		"$addToSet":    bson.M{"courses": courseID},
+		"$set":         bson.M{"updated_at": now},
+		"$setOnInsert": bson.M{"search_name": models.TagSearchName(name), "created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	//2. A concurrent attach may insert the tag first, the retry updates it;
+	//a deleted tag keeps its name so it can't be inserted again
+	err = t.Collection.FindOneAndUpdate(ctx, filter, statement, opts).Decode(&tag)
+	if mongo.IsDuplicateKeyError(err) {
+		err = t.Collection.FindOneAndUpdate(ctx, filter, statement, opts).Decode(&tag)
+	}
+	if err != nil {
+		log.Println("TAG REPOSITORY ATTACH COURSE: ", err.Error())
+		return models.Tag{}, wrapError(err)
+	}
+
+	return tag, nil
+}
+
+func (t TagDatabaseRepository) AttachCourses(ctx context.Context, tags []models.TagCourses) (failures []error, err error) {
+
+	//1. Each tag is a single upsert adding all of its courses, synonyms are resolved first
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(tags))
+	for _, tag := range tags {
+
+		filter := bson.M{"name": tag.Name, "deleted_at": nil}
+		resolved, err := t.resolve(ctx, tag.Name)
+		switch {
+		case err == nil:
+			filter = bson.M{"_id": resolved.ID, "deleted_at": nil}
+		case !errors.Is(err, domain_errors.ErrNotFound):
+			return nil, err
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{
+				"$addToSet":    bson.M{"courses": bson.M{"$each": tag.Courses}},
+				"$set":         bson.M{"updated_at": now},
+				"$setOnInsert": bson.M{"search_name": models.TagSearchName(tag.Name), "created_at": now},
+			}).
+			SetUpsert(true))
+	}
+
+	//2. Unordered, so a failing tag doesn't stop the others; tags inserted concurrently are retried once
+	failures = make([]error, len(tags))
+	pending := make([]int, len(tags))
+	for i := range tags {
+		pending[i] = i
+	}
+
+	for attempt := 0; attempt < 2 && len(pending) > 0; attempt++ {
+
+		batch := make([]mongo.WriteModel, 0, len(pending))
+		for _, i := range pending {
+			batch = append(batch, writes[i])
+		}
+
+		_, err = t.Collection.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+
+		var bulkWriteException mongo.BulkWriteException
+		if err != nil && !errors.As(err, &bulkWriteException) {
+			log.Println("TAG REPOSITORY ATTACH COURSES: ", err.Error())
+			return nil, wrapError(err)
+		}
+
+		retry := make([]int, 0)
+		for _, writeError := range bulkWriteException.WriteErrors {
+			i := pending[writeError.Index]
+			switch {
+			case mongo.IsDuplicateKeyError(writeError.WriteError) && attempt == 0:
+				retry = append(retry, i)
+			case mongo.IsDuplicateKeyError(writeError.WriteError):
+				failures[i] = domain_errors.Conflict("tag "+tags[i].Name+" was deleted, its name can't be reused", writeError.WriteError)
+			default:
+				failures[i] = wrapError(writeError.WriteError)
+			}
+		}
+		pending = retry
+	}
+
+	return failures, nil
+}
+
+func (t TagDatabaseRepository) Search(ctx context.Context, prefix string, limit int64) (tags []models.TagSuggestion, err error) {
+
+	//1. An anchored prefix is answered by the search_name index
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"search_name": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}, "deleted_at": nil}}},
+		//2. Rank by the courses carrying the tag, ties by name
+		{{Key: "$project", Value: bson.M{"name": 1, "course_count": bson.M{"$size": bson.M{"$ifNull": bson.A{"$courses", bson.A{}}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "course_count", Value: -1}, {Key: "name", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := t.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Println("TAG REPOSITORY SEARCH: ", err.Error())
+		return nil, wrapError(err)
+	}
+
+	tags = make([]models.TagSuggestion, 0)
+	err = cursor.All(ctx, &tags)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return tags, nil
+}
+
+// resolve find a tag by its exact name, then by a synonym
+func (t TagDatabaseRepository) resolve(ctx context.Context, name string) (tag models.Tag, err error) {
+
+	err = t.Collection.FindOne(ctx, bson.M{"name": name, "deleted_at": nil}).Decode(&tag)
+	if err == nil || !errors.Is(err, mongo.ErrNoDocuments) {
+		return tag, wrapError(err)
+	}
+
+	var synonym models.TagSynonym
+	err = t.Synonyms.FindOne(ctx, bson.M{"synonym": strings.ToLower(strings.TrimSpace(name))}).Decode(&synonym)
+	if err != nil {
+		return models.Tag{}, wrapError(err)
+	}
+
+	err = t.Collection.FindOne(ctx, bson.M{"_id": synonym.TagID, "deleted_at": nil}).Decode(&tag)
+	if err != nil {
+		return models.Tag{}, wrapError(err)
+	}
+
+	return tag, nil
+}
+
+// ancestors list the ids of the tags above the tag, up to the root
+func (t TagDatabaseRepository) ancestors(ctx context.Context, tagID primitive.ObjectID) ([]primitive.ObjectID, error) {
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": tagID, "deleted_at": nil}}},
+		{{Key: "$graphLookup", Value: bson.M{
+			"from":             t.Collection.Name(),
+			"startWith":        "$parent",
+			"connectFromField": "parent",
+			"connectToField":   "_id",
+			"as":               "ancestors",
+		}}},
+		{{Key: "$project", Value: bson.M{"ancestors": "$ancestors._id"}}},
+	}
+
+	cursor, err := t.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Println("TAG REPOSITORY ANCESTORS: ", err.Error())
+		return nil, wrapError(err)
+	}
+
+	var result []struct {
+		Ancestors []primitive.ObjectID `bson:"ancestors"`
+	}
+	err = cursor.All(ctx, &result)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	if len(result) == 0 {
+		return nil, domain_errors.NotFound("parent tag not found", nil)
+	}
+
+	return result[0].Ancestors, nil
+}
+
+func ConstructTagDBRepository(conn *mongo.Database, coll *mongo.Collection, synonyms *mongo.Collection) contracts.TagDBRepository {
+	return &TagDatabaseRepository{
+		Connection: conn,
+		Collection: coll,
+		Synonyms:   synonyms,
+	}
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// wrapError translate a driver error into a domain error, the driver error is kept as the cause
+func wrapError(err error) error {
+
+	var domainError *domain_errors.Error
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &domainError):
+		return err
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return domain_errors.NotFound("document not found", err)
+	case mongo.IsDuplicateKeyError(err):
+		return domain_errors.Conflict("document already exists", err)
+	case errors.Is(err, primitive.ErrInvalidHex):
+		return domain_errors.InvalidArgument("invalid id", err)
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err), errors.Is(err, context.DeadlineExceeded):
+		return domain_errors.Unavailable("database is unavailable", err)
+	default:
+		return err
+	}
+}
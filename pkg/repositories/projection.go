@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// projectionStatement translate a projection into a mongo projection document
+func projectionStatement(projection models.Projection) bson.M {
+
+	statement := bson.M{}
+	for _, field := range projection.Fields {
+		statement[field] = 1
+	}
+	for _, field := range projection.Exclude {
+		statement[field] = 0
+	}
+
+	return statement
+}
+
+// removeField returns the fields without 'field'
+func removeField(fields []string, field string) []string {
+	kept := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f != field {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
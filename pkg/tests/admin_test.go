@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/admin"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"strings"
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+
+	first, saved := models.GenerateObjectID(), models.GenerateObjectID()
+	cart := models.Cart{
+		UserID:       "user-1",
+		Courses:      []models.Course{{ID: first, Name: "Go", Quantity: 3}},
+		SavedCourses: []models.Course{{ID: saved, Name: "Mongo"}},
+		Version:      4,
+	}
+
+	t.Run("CartShow_Table+", func(t *testing.T) {
+		var output bytes.Buffer
+		cli := admin.Admin{CartUsecase: &fakeCartUsecase{cart: cart}, Output: &output}
+
+		err := cli.Run(context.TODO(), []string{"cart", "show", "user-1"})
+		assert.Equal(t, err, nil)
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		assert.Equal(t, len(lines), 3)
+		assert.Equal(t, strings.Fields(lines[1]), []string{first.Hex(), "Go", "3", "cart"})
+		assert.Equal(t, strings.Fields(lines[2]), []string{saved.Hex(), "Mongo", "1", "saved"})
+	})
+
+	t.Run("CartShow_JSON+", func(t *testing.T) {
+		var output bytes.Buffer
+		cli := admin.Admin{CartUsecase: &fakeCartUsecase{cart: cart}, Output: &output, JSON: true}
+
+		err := cli.Run(context.TODO(), []string{"cart", "show", "user-1"})
+		assert.Equal(t, err, nil)
+
+		var shown models.Cart
+		if err := json.Unmarshal(output.Bytes(), &shown); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, shown.Courses[0].ID, first)
+	})
+
+	t.Run("CartClear_AtTheReadVersion+", func(t *testing.T) {
+		cartUsecase := &fakeCartUsecase{cart: cart}
+		cli := admin.Admin{CartUsecase: cartUsecase, Output: &bytes.Buffer{}}
+
+		err := cli.Run(context.TODO(), []string{"cart", "clear", "user-1"})
+		assert.Equal(t, err, nil)
+
+		revoked := cartUsecase.requests[len(cartUsecase.requests)-1].(requests.RevokeCourseCartRequest)
+		assert.Equal(t, revoked.Courses, []requests.Course{{ID: first.Hex()}})
+		assert.Equal(t, revoked.Version, int64(4))
+	})
+
+	t.Run("CartAdd_WithSeats+", func(t *testing.T) {
+		cartUsecase := &fakeCartUsecase{}
+		cli := admin.Admin{CartUsecase: cartUsecase, Output: &bytes.Buffer{}}
+
+		err := cli.Run(context.TODO(), []string{"cart", "add", "user-1", first.Hex(), "2"})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, cartUsecase.requests, []interface{}{first.Hex(), requests.PutCartItemRequest{Quantity: 2}})
+	})
+
+	t.Run("BookmarkExport_IsJSON+", func(t *testing.T) {
+		var output bytes.Buffer
+		bookmarkUsecase := &fakeBookmarkUsecase{bookmark: models.Bookmark{UserID: "user-1", Courses: []models.Course{{ID: first, Name: "Go"}}}}
+		cli := admin.Admin{BookmarkUsecase: bookmarkUsecase, Output: &output}
+
+		err := cli.Run(context.TODO(), []string{"bookmark", "export", "user-1"})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, json.Valid(output.Bytes()), true)
+	})
+
+	t.Run("Reindex_Fails-", func(t *testing.T) {
+		var output bytes.Buffer
+		indexErr := errors.New("E11000 duplicate key error collection: acourse.bookmarks index: user_id_1")
+		cli := admin.Admin{Reindex: func() error { return indexErr }, Output: &output}
+
+		err := cli.Run(context.TODO(), []string{"reindex"})
+		assert.Equal(t, err, indexErr)
+		assert.Equal(t, output.Len(), 0)
+	})
+
+	t.Run("Usage-", func(t *testing.T) {
+		cli := admin.Admin{Output: &bytes.Buffer{}}
+
+		for _, args := range [][]string{{}, {"cart", "show"}, {"cart", "add", "user-1", first.Hex(), "0"}, {"order", "show", "user-1"}} {
+			err := cli.Run(context.TODO(), args)
+			assert.Equal(t, errors.Is(err, admin.ErrUsage), true)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+func TestBookmarkFilter(t *testing.T) {
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("fetch with filter", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch),
+		)
+
+		courseID := models.GenerateObjectID()
+		createdFrom := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+		_, err := bookmarkDBRepo.Fetch(context.TODO(), models.Projection{}, models.BookmarkFilter{
+			UserIDPrefix: "8.*",
+			CourseID:     courseID,
+			CreatedFrom:  &createdFrom,
+			MinCourses:   3,
+		}, &models.Pagination{Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mt.GetStartedEvent()
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+
+		assert.Equal(t, filter.Lookup("user_id", "$regex").StringValue(), `^8\.\*`)
+		assert.Equal(t, filter.Lookup("courses.id").ObjectID(), courseID)
+		assert.Equal(t, filter.Lookup("created_at", "$gte").Time().Equal(createdFrom), true)
+		assert.Equal(t, filter.Lookup("courses.2", "$exists").Boolean(), true)
+		assert.Equal(t, filter.Lookup("deleted_at").Type.String(), "null")
+	})
+}
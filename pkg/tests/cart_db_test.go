@@ -4,11 +4,12 @@ import (
 	"acourse_tag_cart_bookmark_service/pkg/config"
 	"acourse_tag_cart_bookmark_service/pkg/database"
 	"acourse_tag_cart_bookmark_service/pkg/database/migrations"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/models"
 	"acourse_tag_cart_bookmark_service/pkg/repositories"
 	"context"
+	"errors"
 	"github.com/go-playground/assert/v2"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"math/rand"
 	"strconv"
@@ -32,7 +33,7 @@ func TestCartDBRepo(t *testing.T) {
 	//
 	CartDBRepo := repositories.ConstructCartDBRepository(
 		db.GetConnection(),
-		db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]))
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]), 0)
 
 	timeNow := time.Now()
 
@@ -67,7 +68,7 @@ func TestCartDBRepo(t *testing.T) {
 
 	t.Run("FetchById+", func(t *testing.T) {
 
-		cart, err := CartDBRepo.FetchById(context.TODO(), cartID.Hex(), []string{})
+		cart, err := CartDBRepo.FetchById(context.TODO(), cartID.Hex(), models.Projection{})
 		if err != nil {
 			t.Fatal(err.Error())
 		}
@@ -76,7 +77,7 @@ func TestCartDBRepo(t *testing.T) {
 	})
 
 	t.Run("FetchByUserID+", func(t *testing.T) {
-		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, []string{})
+		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, models.Projection{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -85,76 +86,78 @@ func TestCartDBRepo(t *testing.T) {
 
 	t.Run("AddCourseToACart+", func(t *testing.T) {
 
-		status, err := CartDBRepo.AddCourse(context.TODO(), userId, []string{courseID2.Hex()})
+		version, err := CartDBRepo.AddCourse(context.TODO(), userId, []models.Course{{ID: courseID2}}, 0)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, []string{})
+		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, models.Projection{})
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		assert.Equal(t, status, true)
+		assert.Equal(t, version, cart.Version)
 		assert.Equal(t, cart.Courses[1].ID, courseID2)
 	})
 
 	t.Run("AddCourseToACart_WithInvalidUserID-", func(t *testing.T) {
-		status, err := CartDBRepo.AddCourse(context.TODO(), "invaliduserid", []string{"ivalidhexid1", "invalidhexid2"})
+		//invalid course ids are skipped by the usecase, no course is left to create a cart with
+		version, err := CartDBRepo.AddCourse(context.TODO(), "invaliduserid", []models.Course{}, 0)
 		if err == nil {
 			t.Fatal("Something went wrong! this should raises error no document in result")
 		}
-		assert.Equal(t, status, false)
-		assert.Equal(t, err, mongo.ErrNoDocuments)
+		assert.Equal(t, version, int64(0))
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
 
 	})
 
 	t.Run("AddCourseToACart_WithInvalidCourseID-", func(t *testing.T) {
-		status, err := CartDBRepo.AddCourse(context.TODO(), userId, []string{"ivalidhexid1", "invalidhexid2"})
+		version, err := CartDBRepo.AddCourse(context.TODO(), userId, []models.Course{}, 0)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, []string{})
+		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, models.Projection{})
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		assert.Equal(t, status, true)
+		assert.Equal(t, version, cart.Version)
 		assert.Equal(t, len(cart.Courses), 2)
 	})
 
 	t.Run("RevokeCourseFromCart+", func(t *testing.T) {
-		status, err := CartDBRepo.RevokeCourse(context.TODO(), userId, []string{courseID1.Hex()})
+		version, err := CartDBRepo.RevokeCourse(context.TODO(), userId, []string{courseID1.Hex()}, 0)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, []string{})
+		cart, err := CartDBRepo.FetchByUserId(context.TODO(), userId, models.Projection{})
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		assert.Equal(t, status, true)
+		assert.Equal(t, version, cart.Version)
 		assert.Equal(t, len(cart.Courses), 1)
 		assert.Equal(t, cart.Courses[0].ID, courseID2)
 	})
 
 	t.Run("RevokeCourse_WithInvalidUserId-", func(t *testing.T) {
-		status, err := CartDBRepo.RevokeCourse(context.TODO(), "invaliduserid", []string{})
+		version, err := CartDBRepo.RevokeCourse(context.TODO(), "invaliduserid", []string{}, 0)
 		if err == nil {
 			t.Fatal("This should raises error no document in result")
 		}
-		assert.Equal(t, status, false)
-		assert.Equal(t, err, mongo.ErrNoDocuments)
+		assert.Equal(t, version, int64(0))
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
 
 	})
 
 	t.Run("RevokeCourse_WithNoExistsCourseID-", func(t *testing.T) {
 
-		status, _ := CartDBRepo.RevokeCourse(context.TODO(), userId, []string{courseID1.Hex()})
+		version, err := CartDBRepo.RevokeCourse(context.TODO(), userId, []string{courseID1.Hex()}, 0)
 
-		assert.Equal(t, status, true)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, version > 0, true)
 
 	})
 
@@ -206,43 +209,43 @@ func TestCartDBRepo(t *testing.T) {
 
 	t.Run("FetchById_NoExistDocument-", func(t *testing.T) {
 
-		_, err := CartDBRepo.FetchById(context.TODO(), cartID.Hex(), []string{})
+		_, err := CartDBRepo.FetchById(context.TODO(), cartID.Hex(), models.Projection{})
 
-		assert.Equal(t, err, mongo.ErrNoDocuments)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
 	})
 
 	t.Run("FetchById_WithInvalidHexID-", func(t *testing.T) {
 
-		_, err := CartDBRepo.FetchById(context.TODO(), "terekjkdfjdfhd", []string{})
+		_, err := CartDBRepo.FetchById(context.TODO(), "terekjkdfjdfhd", models.Projection{})
 
-		assert.Equal(t, err, primitive.ErrInvalidHex)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
 	})
 
 	t.Run("FetchByUserID-", func(t *testing.T) {
-		_, err := CartDBRepo.FetchByUserId(context.TODO(), userId, []string{})
+		_, err := CartDBRepo.FetchByUserId(context.TODO(), userId, models.Projection{})
 		if err == nil {
 			t.Fatal("Something went wrong, user id not match and should raises error")
 		}
-		assert.Equal(t, err, mongo.ErrNoDocuments)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
 	})
 
 	t.Run("FetchById_WithInvalidHex", func(t *testing.T) {
-		_, err := CartDBRepo.FetchById(context.TODO(), "thisisarandomhexformat", []string{})
+		_, err := CartDBRepo.FetchById(context.TODO(), "thisisarandomhexformat", models.Projection{})
 
-		assert.Equal(t, err, primitive.ErrInvalidHex)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
 	})
 
 	t.Run("Delete_NoExistsDocument-", func(t *testing.T) {
 		status, err := CartDBRepo.Delete(context.TODO(), models.GenerateObjectID().Hex())
 
 		assert.Equal(t, status, false)
-		assert.Equal(t, err, mongo.ErrNoDocuments)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
 	})
 
 	t.Run("Delete_WithInvalidHex-", func(t *testing.T) {
 		_, err := CartDBRepo.Delete(context.TODO(), "thisisarandomhexformat")
 
-		assert.Equal(t, err, primitive.ErrInvalidHex)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
 	})
 
 }
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeCartRepository records the courses written to a cart and serves a fixed cart
+type fakeCartRepository struct {
+	contracts.CartDBRepository
+	cart    models.Cart
+	written []models.Course
+}
+
+func (f *fakeCartRepository) AddCourse(ctx context.Context, userID string, courses []models.Course, version int64) (int64, error) {
+	f.written = courses
+	return f.cart.Version + 1, nil
+}
+
+func (f *fakeCartRepository) PutCourse(ctx context.Context, userID string, course models.Course, version int64) (int64, error) {
+	f.written = []models.Course{course}
+	return f.cart.Version + 1, nil
+}
+
+func (f *fakeCartRepository) FetchByUserId(ctx context.Context, userID string, projection models.Projection) (models.Cart, error) {
+	return f.cart, nil
+}
+
+func TestCartSeats(t *testing.T) {
+
+	courseID := models.GenerateObjectID()
+	repository := &fakeCartRepository{}
+	courseService := &fakeCourseService{names: map[string]string{courseID.Hex(): "Go"}}
+	cartUsecase := usecase.ConstructCartUsecase(repository, nil, courseService)
+	ctx := context.Background()
+
+	t.Run("Gift_OneRecipientPerSeat+", func(t *testing.T) {
+		_, err := cartUsecase.PutCourse(ctx, "user-1", courseID.Hex(), &requests.PutCartItemRequest{
+			Quantity:   2,
+			Recipients: []string{"Ana@Example.com", "budi@example.com"},
+		})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, repository.written[0].Quantity, int64(2))
+		assert.Equal(t, repository.written[0].Recipients, []string{"ana@example.com", "budi@example.com"})
+	})
+
+	t.Run("Gift_QuantityDefaultsToRecipients+", func(t *testing.T) {
+		_, err := cartUsecase.AddCourse(ctx, &requests.AddCourseCartRequest{
+			UserID:  "user-1",
+			Courses: []requests.CartItem{{ID: courseID.Hex(), Recipients: []string{"ana@example.com", "budi@example.com", "citra@example.com"}}},
+		}, "user-1")
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, repository.written[0].Seats(), int64(3))
+	})
+
+	t.Run("Gift_QuantityMismatch-", func(t *testing.T) {
+		_, err := cartUsecase.PutCourse(ctx, "user-1", courseID.Hex(), &requests.PutCartItemRequest{
+			Quantity:   3,
+			Recipients: []string{"ana@example.com"},
+		})
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+	})
+
+	t.Run("Gift_DuplicateRecipient-", func(t *testing.T) {
+		_, err := cartUsecase.PutCourse(ctx, "user-1", courseID.Hex(), &requests.PutCartItemRequest{
+			Recipients: []string{"ana@example.com", "ANA@example.com"},
+		})
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+	})
+
+	t.Run("Summary_CountsSeats+", func(t *testing.T) {
+		repository.cart = models.Cart{UserID: "user-1", Courses: []models.Course{
+			{ID: courseID},
+			{ID: models.GenerateObjectID(), Quantity: 5},
+			{ID: models.GenerateObjectID(), Quantity: 2, Recipients: []string{"ana@example.com", "budi@example.com"}},
+		}}
+
+		summary, err := cartUsecase.Summary(ctx, "user-1")
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, summary, models.CartSummary{Items: 3, Seats: 8, GiftedSeats: 2})
+	})
+
+	t.Run("Fetch_KeepsSeats+", func(t *testing.T) {
+		repository.cart = models.Cart{UserID: "user-1", Courses: []models.Course{{ID: courseID, Quantity: 4}}}
+
+		cart, err := cartUsecase.FetchByUserId(ctx, "user-1", models.Projection{})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, cart.Courses[0].Name, "Go")
+		assert.Equal(t, cart.Courses[0].Quantity, int64(4))
+	})
+
+	t.Run("Handler_ValidatesRecipients-", func(t *testing.T) {
+		router := newTestRouter(nil, cartUsecase)
+		request := httptest.NewRequest(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID.Hex(), strings.NewReader(`{"quantity":1,"recipients":["not-an-email"]}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		assert.Equal(t, response.Code, http.StatusBadRequest)
+		assert.Equal(t, strings.Contains(response.Body.String(), `"rule":"email"`), true)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("put upserts the cart and keeps recipients literal", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(bson.D{{Key: "version", Value: int64(1)}}))
+
+		version, err := cartDBRepo.PutCourse(context.TODO(), "user-1", models.Course{ID: courseID, Quantity: 1, Recipients: []string{"$ana@example.com"}}, 0)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, version, int64(1))
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, command.Lookup("upsert").Boolean(), true)
+		assert.Equal(t, strings.Contains(command.Lookup("update").String(), `{"$literal": ["$ana@example.com"]}`), true)
+	})
+}
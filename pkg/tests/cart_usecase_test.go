@@ -29,7 +29,7 @@ func TestCartUsecase(t *testing.T) {
 	//
 	CartDBRepo := repositories.ConstructCartDBRepository(
 		db.GetConnection(),
-		db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]))
+		db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"]), 0)
 
 	cartUsecase := usecase.CartUsecase{DBRepository: CartDBRepo}
 
@@ -40,18 +40,18 @@ func TestCartUsecase(t *testing.T) {
 
 	t.Run("AddCourse+", func(t *testing.T) {
 
-		status, err := cartUsecase.AddCourse(context.TODO(), &requests.AddCourseCartRequest{
+		version, err := cartUsecase.AddCourse(context.TODO(), &requests.AddCourseCartRequest{
 			UserID:  userID,
-			Courses: []requests.Course{{ID: courseID1.Hex()}, {ID: courseID2.Hex()}},
+			Courses: []requests.CartItem{{ID: courseID1.Hex()}, {ID: courseID2.Hex()}},
 		}, userID)
 
 		assert.Equal(t, err, nil)
-		assert.Equal(t, status, true)
+		assert.Equal(t, version > 0, true)
 	})
 
 	t.Run("FetchByUserId", func(t *testing.T) {
 
-		cart, err := cartUsecase.FetchByUserId(context.TODO(), userID, []string{})
+		cart, err := cartUsecase.FetchByUserId(context.TODO(), userID, models.Projection{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -61,7 +61,7 @@ func TestCartUsecase(t *testing.T) {
 
 	t.Run("FetchById", func(t *testing.T) {
 
-		cart, err := cartUsecase.FetchById(context.TODO(), cartID, []string{})
+		cart, err := cartUsecase.FetchById(context.TODO(), cartID, models.Projection{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -72,13 +72,13 @@ func TestCartUsecase(t *testing.T) {
 
 		_, err := cartUsecase.AddCourse(context.TODO(), &requests.AddCourseCartRequest{
 			UserID:  userID,
-			Courses: []requests.Course{{ID: courseID1.Hex()}, {ID: courseID2.Hex()}},
+			Courses: []requests.CartItem{{ID: courseID1.Hex()}, {ID: courseID2.Hex()}},
 		}, userID)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		cart, err := cartUsecase.FetchByUserId(context.TODO(), userID, []string{})
+		cart, err := cartUsecase.FetchByUserId(context.TODO(), userID, models.Projection{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -88,7 +88,7 @@ func TestCartUsecase(t *testing.T) {
 
 	t.Run("RevokeCourse+", func(t *testing.T) {
 
-		status, err := cartUsecase.RevokeCourse(context.TODO(), &requests.RevokeCourseCartRequest{
+		version, err := cartUsecase.RevokeCourse(context.TODO(), &requests.RevokeCourseCartRequest{
 			UserID:  userID,
 			Courses: []requests.Course{{ID: courseID1.Hex()}, {ID: courseID2.Hex()}},
 		}, userID)
@@ -97,7 +97,7 @@ func TestCartUsecase(t *testing.T) {
 		}
 
 		assert.Equal(t, err, nil)
-		assert.Equal(t, status, true)
+		assert.Equal(t, version > 0, true)
 	})
 
 }
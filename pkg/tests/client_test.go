@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/client"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+
+	courseID := models.GenerateObjectID()
+	bookmarkUsecase := &fakeBookmarkUsecase{bookmark: models.Bookmark{ID: models.GenerateObjectID(), UserID: "user-1", Courses: []models.Course{{ID: courseID, Name: "Go"}}}}
+	cartUsecase := &fakeCartUsecase{cart: models.Cart{ID: models.GenerateObjectID(), UserID: "user-1", Courses: []models.Course{{ID: courseID, Name: "Go"}}}}
+
+	var authorization string
+	router := newTestRouter(bookmarkUsecase, cartUsecase)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := client.Construct(server.URL, client.WithToken("secret"))
+	ctx := context.Background()
+
+	t.Run("ListBookmarks+", func(t *testing.T) {
+		bookmarks, pagination, err := c.ListBookmarks(ctx, client.BookmarkListOptions{
+			Page:       2,
+			PerPage:    10,
+			Projection: models.Projection{Exclude: []string{"courses"}},
+			Filter:     requests.BookmarkFilterRequest{UserIDPrefix: "user", MinCourses: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(bookmarks), 1)
+		assert.Equal(t, pagination.Page, int64(2))
+		assert.Equal(t, pagination.PerPage, int64(10))
+		assert.Equal(t, pagination.Total, int64(1))
+		assert.Equal(t, authorization, "Bearer secret")
+
+		received := bookmarkUsecase.requests[len(bookmarkUsecase.requests)-3:]
+		assert.Equal(t, received[0].(models.Projection).Exclude, []string{"courses"})
+		assert.Equal(t, received[1], requests.BookmarkFilterRequest{UserIDPrefix: "user", MinCourses: 1})
+	})
+
+	t.Run("Bookmark+", func(t *testing.T) {
+		bookmark, err := c.GetBookmark(ctx, bookmarkUsecase.bookmark.ID.Hex(), models.Projection{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmark.ID, bookmarkUsecase.bookmark.ID)
+
+		bookmark, err = c.GetUserBookmark(ctx, "user-1", models.Projection{Fields: []string{"user_id"}})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmark.UserID, "user-1")
+
+		courses, pagination, err := c.ListBookmarkCourses(ctx, "user-1", requests.CourseListRequest{Sort: "-name"})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, courses[0].ID, courseID)
+		assert.Equal(t, pagination.Total, int64(1))
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.CourseListRequest{Sort: "-name"})
+
+		status, err := c.AddBookmarkCourses(ctx, "user-1", []string{courseID.Hex()})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, status.Status, true)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.AddCourseBookmarkRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID.Hex()}}})
+
+		status, err = c.RemoveBookmarkCourses(ctx, "user-1", []string{courseID.Hex()})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, status.Status, true)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.DeleteAttachedCourseRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID.Hex()}}})
+	})
+
+	t.Run("Cart+", func(t *testing.T) {
+		cart, err := c.GetCart(ctx, cartUsecase.cart.ID.Hex(), models.Projection{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, cart.ID, cartUsecase.cart.ID)
+
+		cart, err = c.GetUserCart(ctx, "user-1", models.Projection{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, cart.Courses[0].Name, "Go")
+
+		courses, _, err := c.ListCartCourses(ctx, "user-1", requests.CourseListRequest{Page: 1, PerPage: 5})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(courses), 1)
+		assert.Equal(t, cartUsecase.requests[len(cartUsecase.requests)-1], requests.CourseListRequest{Page: 1, PerPage: 5})
+
+		status, err := c.AddCartCourses(ctx, "user-1", []string{courseID.Hex()})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, status.Status, true)
+
+		status, err = c.RemoveCartCourses(ctx, "user-1", []string{courseID.Hex()})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, status.Status, true)
+		assert.Equal(t, cartUsecase.requests[len(cartUsecase.requests)-1], requests.RevokeCourseCartRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID.Hex()}}})
+	})
+
+	t.Run("Error_MatchesDomainKind-", func(t *testing.T) {
+		cartUsecase.err = domain_errors.NotFound("cart not found", nil)
+		defer func() { cartUsecase.err = nil }()
+
+		_, err := c.GetUserCart(ctx, "user-2", models.Projection{})
+
+		var apiErr *client.Error
+		assert.Equal(t, errors.As(err, &apiErr), true)
+		assert.Equal(t, apiErr.StatusCode, http.StatusNotFound)
+		assert.Equal(t, apiErr.Message, "cart not found")
+		assert.NotEqual(t, apiErr.RequestID, "")
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
+	})
+
+	t.Run("Retry_IdempotentCalls+", func(t *testing.T) {
+		var calls int32
+		flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			router.ServeHTTP(w, r)
+		}))
+		defer flaky.Close()
+
+		retrying := client.Construct(flaky.URL, client.WithRetries(2, time.Millisecond))
+
+		_, err := retrying.GetUserCart(ctx, "user-1", models.Projection{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, atomic.LoadInt32(&calls), int32(2))
+
+		//PATCH is not idempotent, it is sent once
+		atomic.StoreInt32(&calls, 0)
+		_, err = retrying.AddCartCourses(ctx, "user-1", []string{courseID.Hex()})
+		assert.NotEqual(t, err, nil)
+		assert.Equal(t, atomic.LoadInt32(&calls), int32(1))
+	})
+
+	t.Run("Context_CancelsRetries-", func(t *testing.T) {
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer down.Close()
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := client.Construct(down.URL, client.WithRetries(5, time.Second)).GetUserCart(cancelled, "user-1", models.Projection{})
+		assert.Equal(t, errors.Is(err, context.Canceled), true)
+	})
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestCourseList(t *testing.T) {
+
+	names := []string{"Go Basics", "Rust", "Advanced Go", "Docker"}
+	courseService := &fakeCourseService{names: map[string]string{}}
+	bookmarkRepo := fakeBookmarkRepository{}
+	for _, name := range names {
+		id := models.GenerateObjectID()
+		courseService.names[id.Hex()] = name
+		bookmarkRepo.courses = append(bookmarkRepo.courses, models.Course{ID: id})
+	}
+
+	bookmarkUsecase := usecase.ConstructBookmarkUsecase(bookmarkRepo, nil, courseService)
+
+	t.Run("FetchCourses_ByAddedDate+", func(t *testing.T) {
+		courseService.calls = nil
+
+		courses, pagination, err := bookmarkUsecase.FetchCourses(context.TODO(), "88", &requests.CourseListRequest{PerPage: 3, Sort: "-added_at"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(courses), 3)
+		assert.Equal(t, courses[0].Name, "Docker")
+		assert.Equal(t, pagination.Total, int64(4))
+		assert.Equal(t, pagination.HasNext, true)
+		assert.Equal(t, len(courseService.calls[0]), 3)
+	})
+
+	t.Run("FetchCourses_ByName+", func(t *testing.T) {
+		courses, pagination, err := bookmarkUsecase.FetchCourses(context.TODO(), "88", &requests.CourseListRequest{Page: 1, PerPage: 2, Sort: "name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(courses), 2)
+		assert.Equal(t, courses[0].Name, "Advanced Go")
+		assert.Equal(t, courses[1].Name, "Docker")
+		assert.Equal(t, pagination.HasNext, true)
+	})
+
+	t.Run("FetchCourses_FilterByName+", func(t *testing.T) {
+		courses, pagination, err := bookmarkUsecase.FetchCourses(context.TODO(), "88", &requests.CourseListRequest{Name: "go", Sort: "-name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(courses), 2)
+		assert.Equal(t, courses[0].Name, "Go Basics")
+		assert.Equal(t, pagination.Total, int64(2))
+		assert.Equal(t, pagination.HasNext, false)
+	})
+
+	t.Run("FetchCourses_PageOutOfRange-", func(t *testing.T) {
+		courses, _, err := bookmarkUsecase.FetchCourses(context.TODO(), "88", &requests.CourseListRequest{Page: 5, PerPage: 2, Name: "o"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(courses), 0)
+	})
+
+	t.Run("FetchCourses_ByNameOverTheLimit-", func(t *testing.T) {
+		long := fakeBookmarkRepository{}
+		for i := 0; i < 1001; i++ {
+			long.courses = append(long.courses, models.Course{ID: models.GenerateObjectID()})
+		}
+		courseService.calls = nil
+
+		_, _, err := usecase.ConstructBookmarkUsecase(long, nil, courseService).FetchCourses(context.TODO(), "88", &requests.CourseListRequest{Sort: "name"})
+
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+		assert.Equal(t, len(courseService.calls), 0)
+	})
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"google.golang.org/grpc/codes"
+	"net/http"
+	"testing"
+)
+
+func TestDomainErrors(t *testing.T) {
+
+	t.Run("StatusMapping+", func(t *testing.T) {
+		cases := []struct {
+			err    error
+			status int
+			code   codes.Code
+		}{
+			{domain_errors.NotFound("cart not found", mongo.ErrNoDocuments), http.StatusNotFound, codes.NotFound},
+			{domain_errors.Conflict("document already exists", nil), http.StatusConflict, codes.AlreadyExists},
+			{domain_errors.InvalidArgument("invalid id", nil), http.StatusBadRequest, codes.InvalidArgument},
+			{domain_errors.Unavailable("database is unavailable", nil), http.StatusServiceUnavailable, codes.Unavailable},
+			{domain_errors.PreconditionFailed("cart has changed since version 2", nil), http.StatusPreconditionFailed, codes.FailedPrecondition},
+			{domain_errors.QuotaExceeded("a cart can hold at most 100 courses, it has 100", 100, 100), http.StatusUnprocessableEntity, codes.ResourceExhausted},
+			{domain_errors.TooLarge("body is larger than 4MB", nil), http.StatusRequestEntityTooLarge, codes.ResourceExhausted},
+			{errors.New("boom"), http.StatusInternalServerError, codes.Internal},
+		}
+
+		for _, c := range cases {
+			assert.Equal(t, domain_errors.HTTPStatus(c.err), c.status)
+			assert.Equal(t, domain_errors.GRPCCode(c.err), c.code)
+		}
+	})
+
+	t.Run("Message_HidesInternalErrors+", func(t *testing.T) {
+		assert.Equal(t, domain_errors.Message(domain_errors.NotFound("cart not found", mongo.ErrNoDocuments)), "cart not found")
+		assert.Equal(t, domain_errors.Message(errors.New("connection refused")), "internal server error")
+	})
+
+	t.Run("Cause_IsKept+", func(t *testing.T) {
+		err := domain_errors.NotFound("cart not found", mongo.ErrNoDocuments)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
+		assert.Equal(t, errors.Is(err, mongo.ErrNoDocuments), true)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrConflict), false)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("repository wraps no documents into not found", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch))
+
+		_, err := bookmarkDBRepo.FetchByUserId(context.Background(), "user-1", models.Projection{})
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
+	})
+
+	mt.Run("repository wraps duplicate key into conflict", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index:   0,
+			Code:    11000,
+			Message: "duplicate key error",
+		}))
+
+		_, err := bookmarkDBRepo.Create(context.Background(), &models.Bookmark{ID: models.GenerateObjectID(), UserID: "user-1"})
+		assert.Equal(t, errors.Is(err, domain_errors.ErrConflict), true)
+		assert.Equal(t, domain_errors.HTTPStatus(err), http.StatusConflict)
+	})
+
+	mt.Run("repository rejects invalid ids", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+
+		_, err := bookmarkDBRepo.FetchById(context.Background(), "not-an-id", models.Projection{})
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+	})
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+
+	serve := func(t *testing.T, cartUsecase *fakeCartUsecase, request *http.Request) (*httptest.ResponseRecorder, responses.Envelope) {
+		recorder := httptest.NewRecorder()
+		newTestRouter(nil, cartUsecase).ServeHTTP(recorder, request)
+
+		var envelope responses.Envelope
+		if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+			t.Fatal(err)
+		}
+		return recorder, envelope
+	}
+
+	t.Run("Success_WrapsData+", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/cart/u/user-1", nil)
+		request.Header.Set("X-Request-ID", "request-1")
+
+		recorder, envelope := serve(t, &fakeCartUsecase{cart: models.Cart{UserID: "user-1"}}, request)
+
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, recorder.Header().Get("X-Request-ID"), "request-1")
+		assert.Equal(t, envelope.Error, nil)
+		assert.Equal(t, envelope.Meta.RequestID, "request-1")
+		assert.Equal(t, envelope.Data.(map[string]interface{})["user_id"], "user-1")
+	})
+
+	t.Run("Failure_MapsDomainError-", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/cart/u/user-1", nil)
+
+		recorder, envelope := serve(t, &fakeCartUsecase{err: domain_errors.NotFound("cart not found", nil)}, request)
+
+		assert.Equal(t, recorder.Code, http.StatusNotFound)
+		assert.Equal(t, envelope.Data, nil)
+		assert.Equal(t, envelope.Error.Code, "NOT_FOUND")
+		assert.Equal(t, envelope.Error.Message, "cart not found")
+		assert.NotEqual(t, envelope.Meta.RequestID, "")
+	})
+
+	t.Run("Failure_HidesInternalError-", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/cart/u/user-1", nil)
+
+		recorder, envelope := serve(t, &fakeCartUsecase{err: models.ErrInvalidProjection}, request)
+
+		assert.Equal(t, recorder.Code, http.StatusInternalServerError)
+		assert.Equal(t, envelope.Error.Code, "INTERNAL")
+		assert.Equal(t, envelope.Error.Message, "internal server error")
+	})
+
+	t.Run("Failure_ListsInvalidFields-", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPatch, "/cart/course/add/user-1", strings.NewReader(`{"courses": []}`))
+		request.Header.Set("Content-Type", "application/json")
+
+		recorder, envelope := serve(t, &fakeCartUsecase{}, request)
+
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+		assert.Equal(t, envelope.Error.Code, "INVALID_ARGUMENT")
+		details := envelope.Error.Details.([]interface{})
+		assert.Equal(t, details[0].(map[string]interface{})["field"], "UserID")
+		assert.Equal(t, details[0].(map[string]interface{})["rule"], "required")
+	})
+
+	t.Run("Failure_UnknownRoute-", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+
+		recorder, envelope := serve(t, &fakeCartUsecase{}, request)
+
+		assert.Equal(t, recorder.Code, http.StatusNotFound)
+		assert.Equal(t, envelope.Error.Code, "PAGE_NOT_FOUND")
+	})
+}
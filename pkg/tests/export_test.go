@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/admin"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/controllers"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeRecentlyViewedUsecase serves a fixed list
+type fakeRecentlyViewedUsecase struct {
+	recentlyViewed models.RecentlyViewed
+}
+
+func (f *fakeRecentlyViewedUsecase) Record(ctx context.Context, userID string, courseID string) error {
+	return nil
+}
+
+func (f *fakeRecentlyViewedUsecase) Fetch(ctx context.Context, userID string, request *requests.RecentlyViewedRequest) (models.RecentlyViewed, error) {
+	return f.recentlyViewed, nil
+}
+
+func TestExport(t *testing.T) {
+
+	courseID := models.GenerateObjectID()
+	courseService := &fakeCourseService{names: map[string]string{courseID.Hex(): "Go"}}
+
+	newExportUsecase := func(bookmarkUsecase *fakeBookmarkUsecase) *usecase.ExportUsecase {
+		idempotencyRepository := &fakeIdempotencyRepository{records: map[string]models.IdempotencyRecord{
+			"user-1/key-1": {UserID: "user-1", Key: "key-1", CreatedAt: time.Now()},
+		}}
+		recommendationRepository := &fakeRecommendationRepository{cached: &models.Recommendation{
+			UserID: "user-1", Courses: []models.RecommendedCourse{{ID: courseID, Score: 1}}, ExpiresAt: time.Now().Add(time.Hour),
+		}}
+		return &usecase.ExportUsecase{
+			BookmarkUsecase:            bookmarkUsecase,
+			CartUsecase:                &fakeCartUsecase{err: domain_errors.NotFound("document not found", nil)},
+			RecentlyViewedUsecase:      &fakeRecentlyViewedUsecase{recentlyViewed: models.RecentlyViewed{UserID: "user-1", Courses: []models.ViewedCourse{}}},
+			RecommendationDBRepository: recommendationRepository,
+			IdempotencyDBRepository:    idempotencyRepository,
+			GRPCCourseServiceClient:    courseService,
+		}
+	}
+	bookmark := models.Bookmark{UserID: "user-1", Courses: []models.Course{{ID: courseID, Name: "Go", Tags: []string{"revisit"}}}}
+
+	t.Run("JSON_HasEverySection+", func(t *testing.T) {
+		var output bytes.Buffer
+		err := newExportUsecase(&fakeBookmarkUsecase{bookmark: bookmark}).Export(context.TODO(), "user-1", "json", &output)
+		assert.Equal(t, err, nil)
+
+		var export struct {
+			models.UserExport
+			Bookmark           models.Bookmark            `json:"bookmark"`
+			Cart               *models.Cart               `json:"cart"`
+			Recommendations    models.Recommendation      `json:"recommendations"`
+			IdempotencyRecords []models.IdempotencyRecord `json:"idempotency_records"`
+		}
+		if err := json.Unmarshal(output.Bytes(), &export); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, export.UserID, "user-1")
+		assert.Equal(t, export.Sections, []string{"bookmark", "cart", "recently_viewed", "recommendations", "idempotency_records"})
+		assert.Equal(t, export.Bookmark.Courses[0].Tags, []string{"revisit"})
+		assert.Equal(t, export.Cart == nil, true)
+		assert.Equal(t, export.Recommendations.Courses[0].Name, "Go")
+		assert.Equal(t, export.IdempotencyRecords[0].Key, "key-1")
+	})
+
+	t.Run("ZIP_HasAFilePerSection+", func(t *testing.T) {
+		var output bytes.Buffer
+		cli := admin.Admin{ExportUsecase: newExportUsecase(&fakeBookmarkUsecase{bookmark: bookmark}), Output: &output}
+		err := cli.Run(context.TODO(), []string{"export", "user-1", "zip"})
+		assert.Equal(t, err, nil)
+
+		archive, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names := make([]string, 0)
+		for _, file := range archive.File {
+			names = append(names, file.Name)
+		}
+		assert.Equal(t, names, []string{"export.json", "bookmark.json", "cart.json", "recently_viewed.json", "recommendations.json", "idempotency_records.json"})
+	})
+
+	t.Run("FirstSectionFails_NothingWritten-", func(t *testing.T) {
+		var output bytes.Buffer
+		err := newExportUsecase(&fakeBookmarkUsecase{err: domain_errors.Unavailable("database is unavailable", nil)}).Export(context.TODO(), "user-1", "zip", &output)
+
+		assert.Equal(t, errors.Is(err, domain_errors.ErrUnavailable), true)
+		assert.Equal(t, output.Len(), 0)
+	})
+
+	t.Run("Handler_FailsBeforeWriting-", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		handler := controllers.ExportHandler{ExportUsecase: newExportUsecase(&fakeBookmarkUsecase{err: domain_errors.Unavailable("database is unavailable", nil)})}
+		router.GET("/users/:user_id/export", handler.Export)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/user-1/export?format=zip", nil))
+
+		assert.Equal(t, recorder.Code, http.StatusServiceUnavailable)
+		assert.Equal(t, recorder.Header().Get("Content-Type"), "application/json; charset=utf-8")
+		assert.Equal(t, recorder.Header().Get("Content-Disposition"), "")
+	})
+
+	t.Run("Handler_Attachment+", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		handler := controllers.ExportHandler{ExportUsecase: newExportUsecase(&fakeBookmarkUsecase{bookmark: bookmark})}
+		router.GET("/users/:user_id/export", handler.Export)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/user-1/export?format=zip", nil))
+
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, recorder.Header().Get("Content-Type"), "application/zip")
+		assert.Equal(t, recorder.Header().Get("Content-Disposition"), "attachment; filename=export-user-1.zip")
+	})
+
+	t.Run("Format_MustBeJSONOrZIP-", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		newTestRouter(nil, nil).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/user-1/export?format=xml", nil))
+
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/controllers"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	ps "acourse_tag_cart_bookmark_service/pkg/models/proto_schema"
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"sync"
+)
+
+// fakeCourseService answers course names from a map instead of calling the Course service
+type fakeCourseService struct {
+	names map[string]string
+	calls [][]string
+}
+
+func (f *fakeCourseService) Dial() (ps.CoursesServiceClient, error) {
+	return nil, nil
+}
+
+func (f *fakeCourseService) List(ctx context.Context, coursesID []string) ([]models.Course, error) {
+	f.calls = append(f.calls, coursesID)
+	courses := make([]models.Course, 0)
+	for _, id := range coursesID {
+		courses = append(courses, models.Course{ID: models.GenerateObjectIDFromHex(id), Name: f.names[id]})
+	}
+	return courses, nil
+}
+
+// fakeBookmarkRepository serves the embedded courses from memory, other methods are not implemented
+type fakeBookmarkRepository struct {
+	contracts.BookmarksDBRepository
+	courses []models.Course
+}
+
+func (f fakeBookmarkRepository) FetchCourses(ctx context.Context, userID string, coursesID []primitive.ObjectID, descending bool, limit int64, skip int64) ([]models.Course, int64, error) {
+	courses := make([]models.Course, 0)
+	for _, course := range f.courses {
+		courses = append(courses, course)
+	}
+	if descending {
+		for i, j := 0, len(courses)-1; i < j; i, j = i+1, j-1 {
+			courses[i], courses[j] = courses[j], courses[i]
+		}
+	}
+	total := int64(len(courses))
+	if limit > 0 {
+		if skip > total {
+			skip = total
+		}
+		end := skip + limit
+		if end > total {
+			end = total
+		}
+		courses = courses[skip:end]
+	}
+	return courses, total, nil
+}
+
+func (f fakeBookmarkRepository) FetchByUserId(ctx context.Context, userID string, projection models.Projection) (models.Bookmark, error) {
+	bookmark := models.Bookmark{UserID: userID}
+	if projection.Includes("courses") {
+		bookmark.Courses = f.courses
+	}
+	return bookmark, nil
+}
+
+// fakeAppConfig serves the app config from a map
+type fakeAppConfig map[string]string
+
+func (f fakeAppConfig) GetAppConfig() map[string]string {
+	return f
+}
+
+// fakeCartUsecase serves a fixed cart or error and records the requests it receives
+type fakeCartUsecase struct {
+	cart     models.Cart
+	err      error
+	requests []interface{}
+}
+
+func (f *fakeCartUsecase) FetchById(ctx context.Context, id string, projection models.Projection) (models.Cart, error) {
+	f.requests = append(f.requests, id, projection)
+	return f.cart, f.err
+}
+
+func (f *fakeCartUsecase) FetchByUserId(ctx context.Context, userID string, projection models.Projection) (models.Cart, error) {
+	f.requests = append(f.requests, userID, projection)
+	return f.cart, f.err
+}
+
+func (f *fakeCartUsecase) FetchCourses(ctx context.Context, userID string, request *requests.CourseListRequest) ([]models.Course, models.Pagination, error) {
+	f.requests = append(f.requests, userID, *request)
+	return f.cart.Courses, models.Pagination{Page: 1, PerPage: 25, Total: int64(len(f.cart.Courses))}, f.err
+}
+
+func (f *fakeCartUsecase) AddCourse(ctx context.Context, request *requests.AddCourseCartRequest, userID string) (int64, error) {
+	f.requests = append(f.requests, userID, *request)
+	return f.cart.Version, f.err
+}
+
+func (f *fakeCartUsecase) PutCourse(ctx context.Context, userID string, courseID string, request *requests.PutCartItemRequest) (int64, error) {
+	f.requests = append(f.requests, courseID, *request)
+	return f.cart.Version, f.err
+}
+
+func (f *fakeCartUsecase) Summary(ctx context.Context, userID string) (models.CartSummary, error) {
+	f.requests = append(f.requests, userID)
+	return f.cart.Summary(), f.err
+}
+
+func (f *fakeCartUsecase) SaveForLater(ctx context.Context, userID string, courseID string, version int64) (int64, error) {
+	f.requests = append(f.requests, "save", courseID, version)
+	return f.cart.Version, f.err
+}
+
+func (f *fakeCartUsecase) MoveToCart(ctx context.Context, userID string, courseID string, version int64) (int64, error) {
+	f.requests = append(f.requests, "restore", courseID, version)
+	return f.cart.Version, f.err
+}
+
+func (f *fakeCartUsecase) RemoveSaved(ctx context.Context, userID string, courseID string, version int64) (int64, error) {
+	f.requests = append(f.requests, "remove", courseID, version)
+	return f.cart.Version, f.err
+}
+
+func (f *fakeCartUsecase) RevokeCourse(ctx context.Context, request *requests.RevokeCourseCartRequest, userID string) (int64, error) {
+	f.requests = append(f.requests, userID, *request)
+	return f.cart.Version, f.err
+}
+
+// fakeBookmarkUsecase serves a fixed bookmark or error and records the requests it receives
+type fakeBookmarkUsecase struct {
+	contracts.BookmarkUsecase
+	bookmark models.Bookmark
+	err      error
+	requests []interface{}
+}
+
+func (f *fakeBookmarkUsecase) Fetch(ctx context.Context, projection models.Projection, filter *requests.BookmarkFilterRequest, pagination *models.Pagination) ([]models.Bookmark, error) {
+	f.requests = append(f.requests, projection, *filter, *pagination)
+	pagination.Total = 1
+	return []models.Bookmark{f.bookmark}, f.err
+}
+
+func (f *fakeBookmarkUsecase) FetchById(ctx context.Context, id string, projection models.Projection) (models.Bookmark, error) {
+	f.requests = append(f.requests, id, projection)
+	return f.bookmark, f.err
+}
+
+func (f *fakeBookmarkUsecase) FetchByUserId(ctx context.Context, userID string, projection models.Projection) (models.Bookmark, error) {
+	f.requests = append(f.requests, userID, projection)
+	return f.bookmark, f.err
+}
+
+func (f *fakeBookmarkUsecase) FetchCourses(ctx context.Context, userID string, request *requests.CourseListRequest) ([]models.Course, models.Pagination, error) {
+	f.requests = append(f.requests, userID, *request)
+	return f.bookmark.Courses, models.Pagination{Page: 1, PerPage: 25, Total: int64(len(f.bookmark.Courses))}, f.err
+}
+
+func (f *fakeBookmarkUsecase) Create(ctx context.Context, request *requests.CreateBookmarkRequest) (models.Bookmark, error) {
+	f.requests = append(f.requests, *request)
+	return f.bookmark, f.err
+}
+
+func (f *fakeBookmarkUsecase) AddCourse(ctx context.Context, request *requests.AddCourseBookmarkRequest, userID string) (int64, error) {
+	f.requests = append(f.requests, userID, *request)
+	return f.bookmark.Version, f.err
+}
+
+func (f *fakeBookmarkUsecase) FetchByUserTag(ctx context.Context, userID string, tag string, projection models.Projection) (models.Bookmark, error) {
+	f.requests = append(f.requests, tag, projection)
+	return f.bookmark, f.err
+}
+
+func (f *fakeBookmarkUsecase) SetCourseTags(ctx context.Context, userID string, courseID string, request *requests.CourseTagsRequest) (int64, error) {
+	f.requests = append(f.requests, courseID, *request)
+	return f.bookmark.Version, f.err
+}
+
+func (f *fakeBookmarkUsecase) RevokeCourse(ctx context.Context, request *requests.DeleteAttachedCourseRequest, userID string) (int64, error) {
+	f.requests = append(f.requests, userID, *request)
+	return f.bookmark.Version, f.err
+}
+
+// fakeIdempotencyRepository keeps idempotency records in memory, keyed by user and key;
+// like the database it fails once its context is done
+type fakeIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+	// completeErr fails every Complete
+	completeErr error
+}
+
+func (f *fakeIdempotencyRepository) Create(ctx context.Context, record *models.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.records[record.UserID+"/"+record.Key]; ok {
+		return domain_errors.Conflict("idempotency key already exists", nil)
+	}
+	if f.records == nil {
+		f.records = make(map[string]models.IdempotencyRecord)
+	}
+	f.records[record.UserID+"/"+record.Key] = *record
+	return nil
+}
+
+func (f *fakeIdempotencyRepository) FetchByKey(ctx context.Context, userID string, key string) (models.IdempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, ok := f.records[userID+"/"+key]
+	if !ok {
+		return models.IdempotencyRecord{}, domain_errors.NotFound("idempotency key not found", nil)
+	}
+	return record, nil
+}
+
+func (f *fakeIdempotencyRepository) Complete(ctx context.Context, userID string, key string, response models.IdempotentResponse) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.completeErr != nil {
+		return f.completeErr
+	}
+	record := f.records[userID+"/"+key]
+	record.Response = &response
+	f.records[userID+"/"+key] = record
+	return nil
+}
+
+func (f *fakeIdempotencyRepository) Delete(ctx context.Context, userID string, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.records, userID+"/"+key)
+	return nil
+}
+
+func (f *fakeIdempotencyRepository) FetchByUserId(ctx context.Context, userID string) ([]models.IdempotencyRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := make([]models.IdempotencyRecord, 0)
+	for _, record := range f.records {
+		if record.UserID == userID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// findAndModifyResponse answers an update which reads back the document it wrote, a nil document matched nothing
+func findAndModifyResponse(document interface{}) bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "value", Value: document})
+}
+
+// newTestRouter runs the real routes, usecases which are nil are not implemented
+func newTestRouter(bookmarkUsecase contracts.BookmarkUsecase, cartUsecase contracts.CartUsecase) *gin.Engine {
+	return newTestRouterWithIdempotency(bookmarkUsecase, cartUsecase, nil)
+}
+
+func newTestRouterWithIdempotency(bookmarkUsecase contracts.BookmarkUsecase, cartUsecase contracts.CartUsecase, idempotencyUsecase contracts.IdempotencyUsecase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	var statisticUsecase contracts.StatisticUsecase
+	var courseUsecase contracts.CourseUsecase
+	var recentlyViewedUsecase contracts.RecentlyViewedUsecase
+	var recommendationUsecase contracts.RecommendationUsecase
+	var tagUsecase contracts.TagUsecase
+	var exportUsecase contracts.ExportUsecase
+	controllers.SetupHandler(router, fakeAppConfig{}, &bookmarkUsecase, &cartUsecase, &statisticUsecase, &courseUsecase, &idempotencyUsecase, &recentlyViewedUsecase, &recommendationUsecase, &tagUsecase, &exportUsecase)
+
+	return router
+}
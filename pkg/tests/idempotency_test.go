@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/client"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/middleware"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+
+	courseID := models.GenerateObjectID().Hex()
+	bookmarkUsecase := &fakeBookmarkUsecase{bookmark: models.Bookmark{ID: models.GenerateObjectID(), UserID: "user-1"}}
+	cartUsecase := &fakeCartUsecase{}
+	idempotencyUsecase := usecase.ConstructIdempotencyUsecase(&fakeIdempotencyRepository{}, time.Hour)
+	router := newTestRouterWithIdempotency(bookmarkUsecase, cartUsecase, idempotencyUsecase)
+
+	send := func(method string, path string, key string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			request.Header.Set("Idempotency-Key", key)
+		}
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+	body := `{"courses":[{"id":"` + courseID + `"}]}`
+
+	t.Run("Replay_SameRequest+", func(t *testing.T) {
+		first := send(http.MethodPost, "/v2/users/user-1/bookmarks", "key-1", body)
+		handled := len(bookmarkUsecase.requests)
+
+		retry := send(http.MethodPost, "/v2/users/user-1/bookmarks", "key-1", body)
+
+		assert.Equal(t, first.Code, http.StatusCreated)
+		assert.Equal(t, retry.Code, http.StatusCreated)
+		assert.Equal(t, retry.Body.String(), first.Body.String())
+		assert.Equal(t, retry.Header().Get("Idempotent-Replayed"), "true")
+		assert.Equal(t, first.Header().Get("Idempotent-Replayed"), "")
+		assert.Equal(t, len(bookmarkUsecase.requests), handled)
+	})
+
+	t.Run("Replay_KeepsETag+", func(t *testing.T) {
+		cartUsecase.cart.Version = 5
+		first := send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "key-etag", "")
+		cartUsecase.cart.Version = 6
+
+		retry := send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "key-etag", "")
+		cartUsecase.cart.Version = 0
+
+		assert.Equal(t, first.Header().Get("ETag"), `"5"`)
+		assert.Equal(t, retry.Header().Get("Idempotent-Replayed"), "true")
+		assert.Equal(t, retry.Header().Get("ETag"), `"5"`)
+	})
+
+	t.Run("Conflict_DifferentRequest-", func(t *testing.T) {
+		send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "key-2", "")
+		response := send(http.MethodDelete, "/v2/users/user-1/cart/items/"+courseID, "key-2", "")
+
+		assert.Equal(t, response.Code, http.StatusConflict)
+		assert.Equal(t, strings.Contains(response.Body.String(), `"code":"CONFLICT"`), true)
+	})
+
+	t.Run("ServerError_IsNotReplayed-", func(t *testing.T) {
+		cartUsecase.err = domain_errors.Unavailable("database is down", nil)
+		failed := send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "key-3", "")
+		cartUsecase.err = nil
+
+		retry := send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "key-3", "")
+
+		assert.Equal(t, failed.Code, http.StatusServiceUnavailable)
+		assert.Equal(t, retry.Code, http.StatusOK)
+		assert.Equal(t, retry.Header().Get("Idempotent-Replayed"), "")
+	})
+
+	t.Run("Scope_PerUser+", func(t *testing.T) {
+		handled := len(cartUsecase.requests)
+		send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "key-4", "")
+		response := send(http.MethodPut, "/v2/users/user-2/cart/items/"+courseID, "key-4", "")
+
+		assert.Equal(t, response.Code, http.StatusOK)
+		assert.Equal(t, response.Header().Get("Idempotent-Replayed"), "")
+		assert.Equal(t, len(cartUsecase.requests), handled+4)
+	})
+
+	t.Run("PassThrough_WithoutKeyAndReads+", func(t *testing.T) {
+		handled := len(cartUsecase.requests)
+		send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "", "")
+		send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "", "")
+		read := send(http.MethodGet, "/v2/users/user-1/cart", "key-5", "")
+
+		assert.Equal(t, len(cartUsecase.requests), handled+6)
+		assert.Equal(t, read.Header().Get("Idempotent-Replayed"), "")
+	})
+
+	t.Run("KeyTooLong-", func(t *testing.T) {
+		response := send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, strings.Repeat("k", 256), "")
+		assert.Equal(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("BodyTooLarge-", func(t *testing.T) {
+		response := send(http.MethodPost, "/v2/users/user-1/bookmarks", "key-large", strings.Repeat("a", 1<<20+1))
+
+		assert.Equal(t, response.Code, http.StatusRequestEntityTooLarge)
+		assert.Equal(t, strings.Contains(response.Body.String(), `"code":"TOO_LARGE"`), true)
+	})
+
+	t.Run("Client_RetriesMutationsWithKeys+", func(t *testing.T) {
+		var calls int32
+		flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			router.ServeHTTP(w, r)
+			//the first response is lost after the mutation was handled
+			if atomic.AddInt32(&calls, 1) == 1 {
+				panic(http.ErrAbortHandler)
+			}
+		}))
+		defer flaky.Close()
+
+		handled := len(bookmarkUsecase.requests)
+		c := client.Construct(flaky.URL, client.WithRetries(2, time.Millisecond), client.WithIdempotencyKeys())
+		_, err := c.CreateUserBookmark(context.Background(), "user-1", []string{courseID})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, atomic.LoadInt32(&calls), int32(2))
+		assert.Equal(t, len(bookmarkUsecase.requests), handled+1)
+	})
+
+	t.Run("Replay_KeepsHandlerHeaders+", func(t *testing.T) {
+		handled := 0
+		headers := gin.New()
+		headers.Use(middleware.Idempotency(idempotencyUsecase))
+		headers.PUT("/users/:user_id/things", func(c *gin.Context) {
+			handled++
+			c.Header("ETag", `"7"`)
+			responses.Success(c, http.StatusOK, responses.Status{Status: true})
+		})
+
+		request := func() *httptest.ResponseRecorder {
+			request := httptest.NewRequest(http.MethodPut, "/users/user-1/things", nil)
+			request.Header.Set("Idempotency-Key", "key-6")
+			response := httptest.NewRecorder()
+			headers.ServeHTTP(response, request)
+			return response
+		}
+		request()
+		retry := request()
+
+		assert.Equal(t, handled, 1)
+		assert.Equal(t, retry.Header().Get("Idempotent-Replayed"), "true")
+		assert.Equal(t, retry.Header().Get("ETag"), `"7"`)
+		assert.Equal(t, retry.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	})
+}
+
+func TestIdempotencyCompleteFailure(t *testing.T) {
+
+	courseID := models.GenerateObjectID().Hex()
+	cartUsecase := &fakeCartUsecase{}
+	repository := &fakeIdempotencyRepository{completeErr: domain_errors.Unavailable("database is down", nil)}
+	router := newTestRouterWithIdempotency(nil, cartUsecase, usecase.ConstructIdempotencyUsecase(repository, time.Hour))
+
+	send := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, nil)
+		request.Header.Set("Idempotency-Key", "key-1")
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("Release_WhenResponseIsNotStored+", func(t *testing.T) {
+		first := send()
+		retry := send()
+
+		//The key isn't left 'in progress', the retry is handled again
+		assert.Equal(t, first.Code, http.StatusOK)
+		assert.Equal(t, retry.Code, http.StatusOK)
+		assert.Equal(t, len(repository.records), 0)
+	})
+}
+
+func TestIdempotencyClientGone(t *testing.T) {
+
+	handled := 0
+	var disconnect context.CancelFunc
+	router := gin.New()
+	router.Use(middleware.Idempotency(usecase.ConstructIdempotencyUsecase(&fakeIdempotencyRepository{}, time.Hour)))
+	router.PUT("/users/:user_id/things", func(c *gin.Context) {
+		handled++
+		//the client goes away before the handler returns
+		if disconnect != nil {
+			disconnect()
+		}
+		responses.Success(c, http.StatusOK, responses.Status{Status: true})
+	})
+
+	send := func(ctx context.Context) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPut, "/users/user-1/things", nil).WithContext(ctx)
+		request.Header.Set("Idempotency-Key", "key-1")
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("Disconnect_RetryIsReplayed+", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		disconnect = cancel
+		send(ctx)
+		disconnect = nil
+
+		retry := send(context.Background())
+
+		assert.Equal(t, ctx.Err(), context.Canceled)
+		assert.Equal(t, retry.Code, http.StatusOK)
+		assert.Equal(t, retry.Header().Get("Idempotent-Replayed"), "true")
+		assert.Equal(t, handled, 1)
+	})
+}
+
+func TestIdempotencyScope(t *testing.T) {
+
+	handled := 0
+	router := gin.New()
+	router.Use(middleware.Idempotency(usecase.ConstructIdempotencyUsecase(&fakeIdempotencyRepository{}, time.Hour)))
+	handler := func(c *gin.Context) {
+		handled++
+		responses.Success(c, http.StatusOK, responses.Status{Status: true})
+	}
+	router.POST("/internal/tags/import", handler)
+	router.POST("/internal/tags/export", handler)
+
+	send := func(path string, address string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, nil)
+		request.RemoteAddr = address
+		request.Header.Set("Idempotency-Key", "key-1")
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("WithoutUser_PerCaller+", func(t *testing.T) {
+		send("/internal/tags/import", "192.0.2.1:1234")
+		other := send("/internal/tags/import", "192.0.2.2:1234")
+		retry := send("/internal/tags/import", "192.0.2.1:1234")
+
+		assert.Equal(t, other.Header().Get("Idempotent-Replayed"), "")
+		assert.Equal(t, retry.Header().Get("Idempotent-Replayed"), "true")
+		assert.Equal(t, handled, 2)
+	})
+
+	t.Run("PerRoute+", func(t *testing.T) {
+		handled = 0
+		response := send("/internal/tags/export", "192.0.2.1:1234")
+
+		assert.Equal(t, response.Code, http.StatusOK)
+		assert.Equal(t, response.Header().Get("Idempotent-Replayed"), "")
+		assert.Equal(t, handled, 1)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"acourse_tag_cart_bookmark_service/pkg/repositories"
 	"context"
 	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 	"testing"
 )
@@ -18,7 +19,7 @@ func TestMongo(t *testing.T) {
 		db := mt.Client.Database("acourse")
 		coll := mt.Coll
 
-		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll)
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll, 0)
 
 		objectID := bookmarkDBRepo.GenerateModelID()
 		expected := bookmarkDBRepo.GenerateObjectIDFromString(objectID.Hex())
@@ -32,7 +33,7 @@ func TestMongo(t *testing.T) {
 
 		mt.AddMockResponses(mtest.CreateSuccessResponse())
 
-		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll)
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll, 0)
 
 		bookmarkID := bookmarkDBRepo.GenerateModelID()
 		resID, err := bookmarkDBRepo.Create(context.TODO(), &models.Bookmark{
@@ -55,7 +56,7 @@ func TestMongo(t *testing.T) {
 		db := mt.Client.Database("acourse")
 		coll := mt.Coll
 
-		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll)
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll, 0)
 		//_, _ = bookmarkDBRepo.Create(context.TODO(), &models.Bookmark{
 		//	ID:        bookmarkDBRepo.GenerateModelID(),
 		//	UserID:    "132",
@@ -65,7 +66,7 @@ func TestMongo(t *testing.T) {
 		//	DeletedAt: nil,
 		//})
 
-		bookmarks, err := bookmarkDBRepo.Fetch(context.TODO(), []string{}, 10, 0)
+		bookmarks, err := bookmarkDBRepo.Fetch(context.TODO(), models.Projection{}, models.BookmarkFilter{}, &models.Pagination{Page: 1, PerPage: 10})
 		if err != nil {
 			return
 		}
@@ -77,10 +78,10 @@ func TestMongo(t *testing.T) {
 		db := mt.Client.Database("acourse")
 		coll := mt.Coll
 
-		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll)
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll, 0)
 
 		id := bookmarkDBRepo.GenerateModelID().Hex()
-		bookmark, _ := bookmarkDBRepo.FetchById(context.TODO(), id, []string{})
+		bookmark, _ := bookmarkDBRepo.FetchById(context.TODO(), id, models.Projection{})
 
 		//assert.Equal(t, err, mongo.ErrNoDocuments)
 		assert.Equal(t, bookmark, models.Bookmark{})
@@ -90,14 +91,37 @@ func TestMongo(t *testing.T) {
 		db := mt.Client.Database("acourse")
 		coll := mt.Coll
 
-		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll)
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll, 0)
 
-		status, err := bookmarkDBRepo.RevokeCourse(context.TODO(), bookmarkDBRepo.GenerateModelID().Hex(), []string{"123", "456"})
+		version, err := bookmarkDBRepo.RevokeCourse(context.TODO(), bookmarkDBRepo.GenerateModelID().Hex(), []string{"123", "456"}, 0)
 		if err != nil {
 			return
 		}
 
 		t.Log(err)
-		t.Log(status)
+		t.Log(version)
+	})
+
+	mt.Run("purge course", func(mt *mtest.T) {
+		db := mt.Client.Database("acourse")
+		coll := mt.Coll
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "values", Value: bson.A{"132", "133"}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+		)
+
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(db, coll, 0)
+
+		usersID, modified, err := bookmarkDBRepo.PurgeCourse(context.TODO(), bookmarkDBRepo.GenerateModelID())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, usersID, []string{"132", "133"})
+		assert.Equal(t, modified, int64(2))
+
+		update := mt.GetAllStartedEvents()[1].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, update.Lookup("u", "$set", "updated_at").Type, bson.TypeDateTime)
 	})
 }
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/controllers"
+	"acourse_tag_cart_bookmark_service/pkg/http/openapi"
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+
+	router := newTestRouter(nil, nil)
+
+	t.Run("EveryRouteIsDocumented+", func(t *testing.T) {
+		undocumented := openapi.Undocumented(router.Routes(), controllers.Operations())
+		if len(undocumented) > 0 {
+			t.Errorf("routes without an operation in controllers.Operations: %v", undocumented)
+		}
+
+		unrouted := openapi.Unrouted(router.Routes(), controllers.Operations())
+		if len(unrouted) > 0 {
+			t.Errorf("operations without a route in controllers.SetupHandler: %v", unrouted)
+		}
+	})
+
+	t.Run("ServeDocument+", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		assert.Equal(t, recorder.Code, http.StatusOK)
+
+		var doc openapi.Document
+		if err := json.Unmarshal(recorder.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, doc.OpenAPI, "3.0.3")
+
+		//path params are converted and required
+		operation := doc.Paths["/cart/course/add/{user_id}"]["patch"]
+		assert.Equal(t, operation.Parameters[0].Name, "user_id")
+		assert.Equal(t, operation.Parameters[0].In, "path")
+		assert.Equal(t, operation.Parameters[0].Required, true)
+
+		//binding tags become required fields and constraints
+		body := doc.Components.Schemas["requests.AddCourseCartRequest"]
+		assert.Equal(t, body.Required, []string{"user_id", "courses"})
+		assert.Equal(t, body.Properties["courses"].Items.Ref, "#/components/schemas/requests.CartItem")
+
+		topCourses := doc.Paths["/statistic/top"]["get"]
+		for _, param := range topCourses.Parameters {
+			switch param.Name {
+			case "by":
+				assert.Equal(t, param.Schema.Enum, []string{"bookmarks", "carts"})
+			case "limit":
+				assert.Equal(t, *param.Schema.Minimum, float64(1))
+				assert.Equal(t, *param.Schema.Maximum, float64(100))
+			case "since":
+				assert.Equal(t, param.Schema.Format, "date-time")
+			}
+		}
+
+		//ObjectIDs are strings
+		assert.Equal(t, doc.Components.Schemas["models.Course"].Properties["id"].Type, "string")
+	})
+
+	t.Run("ServeSwaggerUI+", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, strings.Contains(recorder.Body.String(), "/openapi.json"), true)
+		//Nothing is loaded from another origin
+		assert.Equal(t, strings.Contains(recorder.Body.String(), "://"), false)
+	})
+
+	t.Run("ServeSwaggerUIAssets+", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs/assets/../swagger.html", nil))
+		assert.Equal(t, recorder.Code, http.StatusNotFound)
+
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs/assets/swagger-ui-bundle.js", nil))
+		if recorder.Code == http.StatusNotFound {
+			t.Skip("swagger-ui isn't vendored, run 'go generate ./pkg/http/openapi'")
+		}
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, strings.Contains(recorder.Header().Get("Content-Type"), "javascript"), true)
+	})
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+
+	t.Run("CursorRoundTrip+", func(t *testing.T) {
+		id := models.GenerateObjectID()
+
+		decoded, err := models.DecodeCursor(models.EncodeCursor(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, decoded, id)
+	})
+
+	t.Run("DecodeCursor_WithInvalidCursor-", func(t *testing.T) {
+		_, err := models.DecodeCursor("not a cursor")
+		assert.Equal(t, err, models.ErrInvalidCursor)
+
+		_, err = models.DecodeCursor("YWJj")
+		assert.Equal(t, err, models.ErrInvalidCursor)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("fetch bookmarks with next page", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+
+		bookmarkID1 := models.GenerateObjectID()
+		bookmarkID2 := models.GenerateObjectID()
+		bookmarkID3 := models.GenerateObjectID()
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "n", Value: 5}}),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: bookmarkID1}, {Key: "user_id", Value: "1"}},
+				bson.D{{Key: "_id", Value: bookmarkID2}, {Key: "user_id", Value: "2"}},
+				bson.D{{Key: "_id", Value: bookmarkID3}, {Key: "user_id", Value: "3"}}),
+		)
+
+		pagination := models.Pagination{PerPage: 2, Cursor: models.EncodeCursor(models.GenerateObjectID())}
+		bookmarks, err := bookmarkDBRepo.Fetch(context.TODO(), models.Projection{}, models.BookmarkFilter{}, &pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(bookmarks), 2)
+		assert.Equal(t, pagination.Total, int64(5))
+		assert.Equal(t, pagination.HasNext, true)
+		assert.Equal(t, pagination.NextCursor, models.EncodeCursor(bookmarkID2))
+	})
+
+	mt.Run("fetch bookmarks with next page excluding id", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+
+		bookmarkID1 := models.GenerateObjectID()
+		bookmarkID2 := models.GenerateObjectID()
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "n", Value: 3}}),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: bookmarkID1}, {Key: "user_id", Value: "1"}},
+				bson.D{{Key: "_id", Value: bookmarkID2}, {Key: "user_id", Value: "2"}}),
+		)
+
+		pagination := models.Pagination{Page: 1, PerPage: 1}
+		bookmarks, err := bookmarkDBRepo.Fetch(context.TODO(), models.Projection{Exclude: []string{"_id", "courses"}}, models.BookmarkFilter{}, &pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//The cursor moves past the page even though the ids aren't answered
+		assert.Equal(t, len(bookmarks), 1)
+		assert.Equal(t, bookmarks[0].ID, primitive.NilObjectID)
+		assert.Equal(t, pagination.NextCursor, models.EncodeCursor(bookmarkID1))
+
+		projection := mt.GetAllStartedEvents()[1].Command.Lookup("projection").Document()
+		_, err = projection.LookupErr("_id")
+		assert.NotEqual(t, err, nil)
+		assert.Equal(t, projection.Lookup("courses").Int32(), int32(0))
+	})
+
+	mt.Run("fetch bookmarks last page", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: models.GenerateObjectID()}, {Key: "user_id", Value: "1"}}),
+		)
+
+		pagination := models.Pagination{Page: 1, PerPage: 2}
+		bookmarks, err := bookmarkDBRepo.Fetch(context.TODO(), models.Projection{}, models.BookmarkFilter{}, &pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(bookmarks), 1)
+		assert.Equal(t, pagination.HasNext, false)
+		assert.Equal(t, pagination.NextCursor, "")
+	})
+}
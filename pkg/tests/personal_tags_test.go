@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPersonalTags(t *testing.T) {
+
+	courseID := models.GenerateObjectID()
+
+	t.Run("Routes+", func(t *testing.T) {
+		bookmarkUsecase := &fakeBookmarkUsecase{}
+		router := newTestRouter(bookmarkUsecase, nil)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/bookmark/u/user-1?tag=revisit", nil))
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-2], "revisit")
+
+		request := httptest.NewRequest(http.MethodPut, "/v2/users/user-1/bookmarks/items/"+courseID.Hex()+"/tags", strings.NewReader(`{"tags": ["revisit", "for job"]}`))
+		request.Header.Set("Content-Type", "application/json")
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.CourseTagsRequest{Tags: []string{"revisit", "for job"}})
+
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/v2/users/user-1/bookmarks/items/"+courseID.Hex()+"/tags", nil))
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.CourseTagsRequest{})
+	})
+
+	t.Run("TooLongTag-", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPut, "/v2/users/user-1/bookmarks/items/"+courseID.Hex()+"/tags", strings.NewReader(`{"tags": ["`+strings.Repeat("a", 33)+`"]}`))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		newTestRouter(&fakeBookmarkUsecase{}, nil).ServeHTTP(recorder, request)
+
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("tags are lower cased and kept once", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(bson.D{{Key: "version", Value: int64(4)}}))
+
+		bookmarkUsecase := usecase.ConstructBookmarkUsecase(bookmarkDBRepo, nil, nil)
+		version, err := bookmarkUsecase.SetCourseTags(context.TODO(), "user-1", courseID.Hex(), &requests.CourseTagsRequest{Tags: []string{"Revisit", " revisit ", "For Job"}})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, version, int64(4))
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, command.Lookup("query", "courses.id").ObjectID(), courseID)
+		tags, _ := command.Lookup("update", "$set", "courses.$.tags").Array().Values()
+		assert.Equal(t, len(tags), 2)
+		assert.Equal(t, tags[0].StringValue(), "revisit")
+		assert.Equal(t, tags[1].StringValue(), "for job")
+	})
+
+	mt.Run("no tag clears them", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(bson.D{{Key: "version", Value: int64(4)}}))
+
+		_, err := bookmarkDBRepo.SetCourseTags(context.TODO(), "user-1", courseID, nil, 0)
+		assert.Equal(t, err, nil)
+
+		_, err = mt.GetStartedEvent().Command.LookupErr("update", "$unset", "courses.$.tags")
+		assert.Equal(t, err, nil)
+	})
+
+	mt.Run("tagging a course which isn't bookmarked is not found", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(
+			findAndModifyResponse(nil),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "version", Value: int64(3)}, {Key: "attached", Value: false}}),
+		)
+
+		_, err := bookmarkDBRepo.SetCourseTags(context.TODO(), "user-1", courseID, []string{"revisit"}, 0)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
+	})
+
+	mt.Run("filter by tag matches on the tag and keeps the tagged courses", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: models.GenerateObjectID()},
+			{Key: "user_id", Value: "user-1"},
+			{Key: "courses", Value: bson.A{bson.D{{Key: "id", Value: courseID}, {Key: "tags", Value: bson.A{"revisit"}}}}},
+		}))
+
+		bookmark, err := bookmarkDBRepo.FetchByUserTag(context.TODO(), "user-1", "revisit", models.Projection{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmark.Courses[0].Tags, []string{"revisit"})
+
+		match := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Index(0).Value().Document()
+		assert.Equal(t, match.Lookup("$match", "courses.tags").StringValue(), "revisit")
+	})
+
+	mt.Run("bookmark without the tag has no course", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: models.GenerateObjectID()},
+				{Key: "user_id", Value: "user-1"},
+				{Key: "courses", Value: bson.A{bson.D{{Key: "id", Value: courseID}}}},
+			}),
+		)
+
+		bookmark, err := bookmarkDBRepo.FetchByUserTag(context.TODO(), "user-1", "revisit", models.Projection{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmark.UserID, "user-1")
+		assert.Equal(t, len(bookmark.Courses), 0)
+	})
+}
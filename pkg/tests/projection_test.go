@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestProjection(t *testing.T) {
+
+	t.Run("NewProjection_TranslateJsonToBson+", func(t *testing.T) {
+		projection, err := models.NewProjection(models.Bookmark{}, []string{"id", "user_id"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, projection.Fields, []string{"_id", "user_id"})
+		assert.Equal(t, projection.Includes("courses"), false)
+		assert.Equal(t, projection.Includes("_id"), true)
+	})
+
+	t.Run("NewProjection_Exclude+", func(t *testing.T) {
+		projection, err := models.NewProjection(models.Cart{}, nil, []string{"courses"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, projection.Includes("courses"), false)
+		assert.Equal(t, projection.Includes("user_id"), true)
+	})
+
+	t.Run("NewProjection_UnknownField-", func(t *testing.T) {
+		_, err := models.NewProjection(models.Bookmark{}, nil, []string{"password"})
+
+		assert.Equal(t, errors.Is(err, models.ErrInvalidProjection), true)
+	})
+
+	t.Run("NewProjection_FieldsAndExclude-", func(t *testing.T) {
+		_, err := models.NewProjection(models.Bookmark{}, []string{"user_id"}, []string{"courses"})
+
+		assert.Equal(t, errors.Is(err, models.ErrInvalidProjection), true)
+	})
+
+	t.Run("FetchByUserId_WithoutCourses_SkipHydration+", func(t *testing.T) {
+		courseService := &fakeCourseService{names: map[string]string{}}
+		bookmarkRepo := fakeBookmarkRepository{courses: []models.Course{{ID: models.GenerateObjectID()}}}
+		bookmarkUsecase := usecase.ConstructBookmarkUsecase(bookmarkRepo, nil, courseService)
+
+		_, err := bookmarkUsecase.FetchByUserId(context.TODO(), "88", models.Projection{Exclude: []string{"courses"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(courseService.calls), 0)
+
+		bookmark, err := bookmarkUsecase.FetchByUserId(context.TODO(), "88", models.Projection{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(courseService.calls), 1)
+		assert.Equal(t, len(bookmark.Courses), 1)
+	})
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/cmd/grpc_server"
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/http/middleware"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	ps "acourse_tag_cart_bookmark_service/pkg/models/proto_schema"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// purgedBookmarkRepository answers a fixed list of affected users
+type purgedBookmarkRepository struct {
+	contracts.BookmarksDBRepository
+	usersID []string
+}
+
+func (f purgedBookmarkRepository) PurgeCourse(ctx context.Context, courseID primitive.ObjectID) ([]string, int64, error) {
+	return f.usersID, int64(len(f.usersID)), nil
+}
+
+type purgedCartRepository struct {
+	contracts.CartDBRepository
+	usersID []string
+}
+
+func (f purgedCartRepository) PurgeCourse(ctx context.Context, courseID primitive.ObjectID) ([]string, int64, error) {
+	return f.usersID, int64(len(f.usersID)), nil
+}
+
+// blockingNotifier waits for its context, like a delivery channel which doesn't answer
+type blockingNotifier struct {
+	calls int
+}
+
+func (n *blockingNotifier) NotifyCoursePurged(ctx context.Context, userID string, courseID string, source string) error {
+	n.calls++
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestPurgeCourse(t *testing.T) {
+
+	t.Run("Notify_StopsAtTheTimeout-", func(t *testing.T) {
+		notifier := &blockingNotifier{}
+		courseUsecase := &usecase.CourseUsecase{
+			BookmarkDBRepository: purgedBookmarkRepository{usersID: []string{"user-1", "user-2"}},
+			CartDBRepository:     purgedCartRepository{usersID: []string{"user-3"}},
+			Notifier:             notifier,
+			NotifyTimeout:        10 * time.Millisecond,
+		}
+
+		started := time.Now()
+		purge, err := courseUsecase.PurgeCourse(context.TODO(), models.GenerateObjectID().Hex())
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, purge.BookmarksModified, int64(2))
+		assert.Equal(t, purge.NotificationsSent, int64(0))
+		assert.Equal(t, notifier.calls, 1)
+		assert.Equal(t, time.Since(started) < time.Second, true)
+	})
+
+	courseUsecase := usecase.ConstructCourseUsecase(purgedBookmarkRepository{}, purgedCartRepository{}, nil)
+
+	serve := func(t *testing.T, allowed map[string]bool) ps.TagCartBookmarkServiceClient {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		_ = listener.Close()
+
+		server := &grpc_server.GRPCServer{PORT: strconv.Itoa(port), CourseUsecase: courseUsecase, AllowedIPs: allowed}
+		go func() { _ = server.Serve() }()
+
+		conn, err := grpc.Dial("127.0.0.1:"+strconv.Itoa(port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return ps.NewTagCartBookmarkServiceClient(conn)
+	}
+
+	call := func(client ps.TagCartBookmarkServiceClient) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := client.PurgeCourse(ctx, &ps.PurgeCourseRequest{CourseID: models.GenerateObjectID().Hex()}, grpc.WaitForReady(true))
+		return err
+	}
+
+	t.Run("GRPC_RefusesClientsNotWhitelisted-", func(t *testing.T) {
+		err := call(serve(t, map[string]bool{"10.10.10.1": true}))
+		assert.Equal(t, status.Code(err), codes.PermissionDenied)
+	})
+
+	t.Run("GRPC_AllowsWhitelistedClients+", func(t *testing.T) {
+		err := call(serve(t, map[string]bool{"127.0.0.1": true}))
+		assert.Equal(t, err, nil)
+	})
+
+	internal := func(allowed ...string) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.DELETE("/internal/course/:course_id", middleware.Whitelist(allowed...), func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+
+	t.Run("HTTP_RefusesSpoofedForwardedFor-", func(t *testing.T) {
+		//httptest requests come from 192.0.2.1, the header claims the default whitelisted address
+		request := httptest.NewRequest(http.MethodDelete, "/internal/course/"+models.GenerateObjectID().Hex(), nil)
+		request.Header.Set("X-Forwarded-For", "10.10.10.1")
+		request.Header.Set("X-Real-IP", "10.10.10.1")
+		recorder := httptest.NewRecorder()
+		internal().ServeHTTP(recorder, request)
+
+		assert.Equal(t, recorder.Code, http.StatusForbidden)
+	})
+
+	t.Run("HTTP_AllowsWhitelistedClients+", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		internal("192.0.2.1").ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/internal/course/"+models.GenerateObjectID().Hex(), nil))
+
+		assert.Equal(t, recorder.Code, http.StatusOK)
+	})
+}
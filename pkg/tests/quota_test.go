@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/responses"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("add is filtered by the size of the union", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 3)
+		mt.AddMockResponses(findAndModifyResponse(bson.D{{Key: "version", Value: int64(2)}}))
+
+		version, err := cartDBRepo.AddCourse(context.TODO(), "user-1", []models.Course{{ID: models.GenerateObjectID()}}, 0)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, version, int64(2))
+
+		limit := mt.GetStartedEvent().Command.Lookup("query", "$expr", "$lte").Array().Index(1).Value()
+		assert.Equal(t, limit.Int64(), int64(3))
+	})
+
+	mt.Run("full cart is a quota error with the count and the limit", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 3)
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11000, Message: "E11000 duplicate key error"}),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11000, Message: "E11000 duplicate key error"}),
+			mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch, bson.D{{Key: "version", Value: int64(4)}, {Key: "items", Value: int64(3)}}),
+		)
+
+		_, err := cartDBRepo.AddCourse(context.TODO(), "user-1", []models.Course{{ID: models.GenerateObjectID()}}, 0)
+
+		assert.Equal(t, errors.Is(err, domain_errors.ErrQuotaExceeded), true)
+		assert.Equal(t, domain_errors.Details(err), domain_errors.Quota{Current: 3, Limit: 3})
+	})
+
+	mt.Run("too many courses at once fail before the update", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 1)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch))
+
+		_, err := bookmarkDBRepo.AddCourse(context.TODO(), "user-1", []string{models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex()}, 0)
+
+		assert.Equal(t, errors.Is(err, domain_errors.ErrQuotaExceeded), true)
+		assert.Equal(t, domain_errors.Details(err), domain_errors.Quota{Current: 0, Limit: 1})
+		assert.Equal(t, mt.GetStartedEvent().CommandName, "aggregate")
+	})
+
+	mt.Run("created bookmark is checked against the limit", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 1)
+
+		_, err := bookmarkDBRepo.Create(context.TODO(), &models.Bookmark{UserID: "user-1", Courses: []models.Course{{ID: models.GenerateObjectID()}, {ID: models.GenerateObjectID()}}})
+
+		assert.Equal(t, errors.Is(err, domain_errors.ErrQuotaExceeded), true)
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 0)
+	})
+
+	t.Run("Failure_CarriesQuotaDetails-", func(t *testing.T) {
+		cartUsecase := &fakeCartUsecase{err: domain_errors.QuotaExceeded("a cart can hold at most 3 courses, it has 3", 3, 3)}
+		recorder := httptest.NewRecorder()
+		newTestRouter(nil, cartUsecase).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/v2/users/user-1/cart/items/"+models.GenerateObjectID().Hex(), nil))
+
+		var envelope responses.Envelope
+		if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, recorder.Code, http.StatusUnprocessableEntity)
+		assert.Equal(t, envelope.Error.Code, "QUOTA_EXCEEDED")
+		assert.Equal(t, envelope.Error.Details, map[string]interface{}{"current": float64(3), "limit": float64(3)})
+	})
+}
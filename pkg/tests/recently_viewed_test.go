@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+func TestRecentlyViewed(t *testing.T) {
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	courseID := models.GenerateObjectID()
+	updated := mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1})
+
+	mt.Run("view moves the course to the front of a capped list", func(mt *mtest.T) {
+		repo := repositories.ConstructRecentlyViewedDBRepository(mt.Client.Database("acourse"), mt.Coll, 5)
+		mt.AddMockResponses(updated)
+
+		err := repo.Record(context.TODO(), "user-1", courseID, time.Now())
+		assert.Equal(t, err, nil)
+
+		//One update moves the course to the front and cuts the tail
+		events := mt.GetAllStartedEvents()
+		assert.Equal(t, len(events), 1)
+		update := events[0].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, update.Lookup("upsert").Boolean(), true)
+		assert.Equal(t, update.Lookup("q", "user_id").StringValue(), "user-1")
+
+		courses := update.Lookup("u").Array().Index(0).Value().Document().Lookup("$set", "courses", "$slice").Array()
+		assert.Equal(t, courses.Index(1).Value().Int64(), int64(5))
+		concat := courses.Index(0).Value().Document().Lookup("$concatArrays").Array()
+		assert.Equal(t, concat.Index(0).Value().Array().Index(0).Value().Document().Lookup("id").ObjectID(), courseID)
+		assert.Equal(t, concat.Index(1).Value().Document().Lookup("$filter", "cond", "$ne").Array().Index(1).Value().ObjectID(), courseID)
+	})
+
+	mt.Run("concurrent first views insert the list once", func(mt *mtest.T) {
+		repo := repositories.ConstructRecentlyViewedDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		duplicate := mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"})
+		mt.AddMockResponses(duplicate, updated)
+
+		err := repo.Record(context.TODO(), "user-1", courseID, time.Now())
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 2)
+	})
+
+	mt.Run("fetch hydrates the names in view order", func(mt *mtest.T) {
+		repo := repositories.ConstructRecentlyViewedDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		other := models.GenerateObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.recently_viewed", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: models.GenerateObjectID()},
+			{Key: "user_id", Value: "user-1"},
+			{Key: "courses", Value: bson.A{
+				bson.D{{Key: "id", Value: courseID}, {Key: "viewed_at", Value: time.Now()}},
+				bson.D{{Key: "id", Value: other}, {Key: "viewed_at", Value: time.Now().Add(-time.Hour)}},
+			}},
+		}))
+
+		courseService := &fakeCourseService{names: map[string]string{courseID.Hex(): "Go", other.Hex(): "Mongo"}}
+		recentlyViewedUsecase := usecase.ConstructRecentlyViewedUsecase(repo, courseService)
+
+		recentlyViewed, err := recentlyViewedUsecase.Fetch(context.TODO(), "user-1", &requests.RecentlyViewedRequest{Limit: 2})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, recentlyViewed.Courses[0].Name, "Go")
+		assert.Equal(t, recentlyViewed.Courses[1].Name, "Mongo")
+
+		find := mt.GetStartedEvent().Command
+		assert.Equal(t, find.Lookup("projection", "courses", "$slice").Int64(), int64(2))
+	})
+
+	mt.Run("user who viewed nothing has an empty list", func(mt *mtest.T) {
+		repo := repositories.ConstructRecentlyViewedDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.recently_viewed", mtest.FirstBatch))
+
+		recentlyViewed, err := usecase.ConstructRecentlyViewedUsecase(repo, &fakeCourseService{}).Fetch(context.TODO(), "user-2", &requests.RecentlyViewedRequest{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, recentlyViewed.UserID, "user-2")
+		assert.Equal(t, len(recentlyViewed.Courses), 0)
+	})
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+// fakeRecommendationRepository computes fixed courses and keeps the cache in memory
+type fakeRecommendationRepository struct {
+	courses  []models.RecommendedCourse
+	owned    []primitive.ObjectID
+	cached   *models.Recommendation
+	computed int
+}
+
+func (f *fakeRecommendationRepository) Compute(ctx context.Context, userID string, limit int64) ([]models.RecommendedCourse, error) {
+	f.computed++
+	return f.courses, nil
+}
+
+func (f *fakeRecommendationRepository) OwnedCourses(ctx context.Context, userID string) ([]primitive.ObjectID, error) {
+	return f.owned, nil
+}
+
+func (f *fakeRecommendationRepository) FetchCached(ctx context.Context, userID string) (models.Recommendation, error) {
+	if f.cached == nil || !f.cached.ExpiresAt.After(time.Now()) {
+		return models.Recommendation{}, domain_errors.NotFound("document not found", nil)
+	}
+	return *f.cached, nil
+}
+
+func (f *fakeRecommendationRepository) Cache(ctx context.Context, recommendation *models.Recommendation) error {
+	f.cached = recommendation
+	return nil
+}
+
+func TestRecommendation(t *testing.T) {
+
+	first, second, third := models.GenerateObjectID(), models.GenerateObjectID(), models.GenerateObjectID()
+	courseService := &fakeCourseService{names: map[string]string{first.Hex(): "Go", second.Hex(): "Mongo", third.Hex(): "gRPC"}}
+
+	t.Run("Cache_ServesUntilExpired+", func(t *testing.T) {
+		repo := &fakeRecommendationRepository{courses: []models.RecommendedCourse{{ID: first, Score: 2}, {ID: second, Score: 1}}}
+		recommendationUsecase := usecase.ConstructRecommendationUsecase(repo, courseService, time.Minute)
+
+		courses, err := recommendationUsecase.Recommend(context.TODO(), "user-1", &requests.RecommendationRequest{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(courses), 2)
+		assert.Equal(t, courses[0].Name, "Go")
+		assert.Equal(t, repo.cached.ExpiresAt.Sub(repo.cached.CreatedAt), time.Minute)
+
+		_, err = recommendationUsecase.Recommend(context.TODO(), "user-1", &requests.RecommendationRequest{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, repo.computed, 1)
+
+		repo.cached.ExpiresAt = time.Now().Add(-time.Second)
+		_, err = recommendationUsecase.Recommend(context.TODO(), "user-1", &requests.RecommendationRequest{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, repo.computed, 2)
+	})
+
+	t.Run("Owned_AreLeftOut+", func(t *testing.T) {
+		repo := &fakeRecommendationRepository{courses: []models.RecommendedCourse{{ID: first, Score: 3}, {ID: second, Score: 2}, {ID: third, Score: 1}}}
+		recommendationUsecase := usecase.ConstructRecommendationUsecase(repo, courseService, 0)
+
+		//the user carts the best course after the recommendation was cached
+		_, err := recommendationUsecase.Recommend(context.TODO(), "user-1", &requests.RecommendationRequest{})
+		assert.Equal(t, err, nil)
+		repo.owned = []primitive.ObjectID{first}
+
+		courses, err := recommendationUsecase.Recommend(context.TODO(), "user-1", &requests.RecommendationRequest{Limit: 1})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, repo.computed, 1)
+		assert.Equal(t, len(courses), 1)
+		assert.Equal(t, courses[0].ID, second)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("owned courses are the bookmarked, carted and saved courses", func(mt *mtest.T) {
+		repo := repositories.ConstructRecommendationDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll, mt.Coll, mt.Coll)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "courses", Value: bson.A{bson.D{{Key: "id", Value: first}}}}}),
+			mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch, bson.D{
+				{Key: "courses", Value: bson.A{bson.D{{Key: "id", Value: second}}}}, {Key: "saved_courses", Value: bson.A{bson.D{{Key: "id", Value: third}}}},
+			}),
+		)
+
+		owned, err := repo.OwnedCourses(context.TODO(), "user-1")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, owned, []primitive.ObjectID{first, second, third})
+	})
+
+	mt.Run("user without bookmark has no recommendation", func(mt *mtest.T) {
+		repo := repositories.ConstructRecommendationDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll, mt.Coll, mt.Coll)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch))
+
+		courses, err := repo.Compute(context.TODO(), "user-1", 10)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(courses), 0)
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 1)
+	})
+
+	mt.Run("co-occurring courses are scored without the user's courses", func(mt *mtest.T) {
+		repo := repositories.ConstructRecommendationDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll, mt.Coll, mt.Coll)
+		tagID := models.GenerateObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "courses", Value: bson.A{bson.D{{Key: "id", Value: first}}}}}),
+			mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch, bson.D{{Key: "saved_courses", Value: bson.A{bson.D{{Key: "id", Value: second}}}}}),
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: tagID}}),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: third}, {Key: "score", Value: 2.5}, {Key: "co_occurrences", Value: int64(2)}, {Key: "shared_tags", Value: int64(1)},
+			}),
+		)
+
+		courses, err := repo.Compute(context.TODO(), "user-1", 10)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, courses, []models.RecommendedCourse{{ID: third, Score: 2.5, CoOccurrences: 2, SharedTags: 1}})
+
+		events := mt.GetAllStartedEvents()
+		pipeline := events[3].Command.Lookup("pipeline").Array()
+		assert.Equal(t, pipeline.Index(0).Value().Document().Lookup("$match", "user_id", "$ne").StringValue(), "user-1")
+		excluded, _ := pipeline.Index(3).Value().Document().Lookup("$match", "courses", "$nin").Array().Values()
+		assert.Equal(t, len(excluded), 2)
+		assert.Equal(t, pipeline.Index(6).Value().Document().Lookup("$limit").AsInt64(), int64(40))
+		tags, _ := pipeline.Index(7).Value().Document().Lookup("$lookup", "pipeline").Array().Index(0).Value().Document().Lookup("$match", "_id", "$in").Array().Values()
+		assert.Equal(t, tags[0].ObjectID(), tagID)
+	})
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSaveForLater(t *testing.T) {
+
+	courseID := models.GenerateObjectID()
+
+	t.Run("Summary_CountsActiveItems+", func(t *testing.T) {
+		cart := models.Cart{
+			Courses:      []models.Course{{ID: models.GenerateObjectID(), Quantity: 2}},
+			SavedCourses: []models.Course{{ID: courseID, Quantity: 5}, {ID: models.GenerateObjectID()}},
+		}
+
+		assert.Equal(t, cart.Summary(), models.CartSummary{Items: 1, Seats: 2, SavedItems: 2})
+	})
+
+	t.Run("Routes+", func(t *testing.T) {
+		cartUsecase := &fakeCartUsecase{}
+		router := newTestRouter(nil, cartUsecase)
+
+		cases := []struct {
+			method string
+			path   string
+			action string
+		}{
+			{http.MethodPut, "/v2/users/user-1/cart/saved/" + courseID.Hex(), "save"},
+			{http.MethodPost, "/v2/users/user-1/cart/saved/" + courseID.Hex() + "/restore", "restore"},
+			{http.MethodDelete, "/v2/users/user-1/cart/saved/" + courseID.Hex(), "remove"},
+		}
+
+		for _, c := range cases {
+			request := httptest.NewRequest(c.method, c.path, nil)
+			request.Header.Set("If-Match", `"3"`)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, recorder.Code, http.StatusOK)
+			assert.Equal(t, cartUsecase.requests[len(cartUsecase.requests)-3:], []interface{}{c.action, courseID.Hex(), int64(3)})
+		}
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("saving requires the course in the cart and room for it", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(bson.D{{Key: "version", Value: int64(3)}}))
+
+		version, err := cartDBRepo.MoveCourse(context.TODO(), "user-1", courseID, true, 0)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, version, int64(3))
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, command.Lookup("query", "user_id").StringValue(), "user-1")
+		conditions, _ := command.Lookup("query", "$expr", "$and").Array().Values()
+		assert.Equal(t, len(conditions), 2)
+		assert.Equal(t, command.Lookup("update").Type, bson.TypeArray)
+	})
+
+	mt.Run("saving a course which isn't in the cart is not found", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(
+			findAndModifyResponse(nil),
+			mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch, bson.D{{Key: "version", Value: int64(2)}, {Key: "attached", Value: false}, {Key: "items", Value: int64(0)}}),
+		)
+
+		_, err := cartDBRepo.MoveCourse(context.TODO(), "user-1", courseID, true, 0)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
+	})
+
+	mt.Run("full saved list is a quota error", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 2)
+		mt.AddMockResponses(
+			findAndModifyResponse(nil),
+			mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch, bson.D{{Key: "version", Value: int64(2)}, {Key: "attached", Value: true}, {Key: "items", Value: int64(2)}}),
+		)
+
+		_, err := cartDBRepo.MoveCourse(context.TODO(), "user-1", courseID, true, 0)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrQuotaExceeded), true)
+		assert.Equal(t, domain_errors.Details(err), domain_errors.Quota{Current: 2, Limit: 2})
+	})
+
+	mt.Run("adding a saved course moves it back", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(bson.D{{Key: "version", Value: int64(3)}}))
+
+		_, err := cartDBRepo.AddCourse(context.TODO(), "user-1", []models.Course{{ID: courseID}}, 0)
+		assert.Equal(t, err, nil)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("update").Array().Values()
+		last := stages[len(stages)-1].Document()
+		_, err = last.LookupErr("$set", "saved_courses")
+		assert.Equal(t, err, nil)
+	})
+}
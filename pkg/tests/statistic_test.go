@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+func TestStatisticDBRepo(t *testing.T) {
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	courseID1 := models.GenerateObjectID()
+	courseID2 := models.GenerateObjectID()
+
+	mt.Run("count by courses", func(mt *mtest.T) {
+		statisticDBRepo := repositories.ConstructStatisticDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: courseID2}, {Key: "bookmarks", Value: 3}},
+				bson.D{{Key: "_id", Value: courseID1}, {Key: "bookmarks", Value: 1}}),
+			mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: courseID1}, {Key: "carts", Value: 2}}),
+		)
+
+		statistics, err := statisticDBRepo.CountByCourses(context.TODO(), []string{courseID1.Hex(), courseID2.Hex(), courseID1.Hex()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, statistics, []models.CourseStatistic{
+			{CourseID: courseID1, Bookmarks: 1, Carts: 2},
+			{CourseID: courseID2, Bookmarks: 3, Carts: 0},
+		})
+	})
+
+	mt.Run("count by courses with invalid hex", func(mt *mtest.T) {
+		statisticDBRepo := repositories.ConstructStatisticDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+
+		_, err := statisticDBRepo.CountByCourses(context.TODO(), []string{"invalidhexid"})
+
+		assert.NotEqual(t, err, nil)
+	})
+
+	mt.Run("top courses", func(mt *mtest.T) {
+		statisticDBRepo := repositories.ConstructStatisticDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: courseID2}, {Key: "carts", Value: 5}},
+			bson.D{{Key: "_id", Value: courseID1}, {Key: "carts", Value: 4}}))
+
+		since := time.Now().AddDate(0, 0, -7)
+		statistics, err := statisticDBRepo.TopCourses(context.TODO(), models.StatisticSourceCarts, 2, &since, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, len(statistics), 2)
+		assert.Equal(t, statistics[0].CourseID, courseID2)
+		assert.Equal(t, statistics[0].Carts, int64(5))
+	})
+
+	mt.Run("top courses with unknown source", func(mt *mtest.T) {
+		statisticDBRepo := repositories.ConstructStatisticDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+
+		_, err := statisticDBRepo.TopCourses(context.TODO(), "orders", 10, nil, nil)
+
+		assert.NotEqual(t, err, nil)
+	})
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestTagHierarchy(t *testing.T) {
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	backend, golang, courseID := models.GenerateObjectID(), models.GenerateObjectID(), models.GenerateObjectID()
+
+	mt.Run("a synonym expands to the courses of its tag and descendants", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "acourse.tag_synonyms", mtest.FirstBatch, bson.D{{Key: "synonym", Value: "server side"}, {Key: "tag_id", Value: backend}}),
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: backend}, {Key: "name", Value: "Backend"}}),
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: backend}, {Key: "courses", Value: bson.A{courseID}}}),
+		)
+
+		coursesID, err := tagRepo.FetchCoursesByTag(context.TODO(), "Server Side")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, coursesID, []primitive.ObjectID{courseID})
+
+		events := mt.GetAllStartedEvents()
+		assert.Equal(t, events[1].Command.Lookup("filter", "synonym").StringValue(), "server side")
+		pipeline := events[3].Command.Lookup("pipeline").Array()
+		assert.Equal(t, pipeline.Index(0).Value().Document().Lookup("$match", "_id").ObjectID(), backend)
+		assert.Equal(t, pipeline.Index(1).Value().Document().Lookup("$graphLookup", "connectToField").StringValue(), "parent")
+	})
+
+	mt.Run("an unknown tag carries no course", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "acourse.tag_synonyms", mtest.FirstBatch),
+		)
+
+		coursesID, err := tagRepo.FetchCoursesByTag(context.TODO(), "Cobol")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(coursesID), 0)
+	})
+
+	mt.Run("a tag can't move under its descendant", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: golang}, {Key: "ancestors", Value: bson.A{backend}}}))
+
+		err := tagRepo.SetParent(context.TODO(), backend, &golang)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 1)
+	})
+
+	mt.Run("a tag can't be its own parent", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+
+		err := tagRepo.SetParent(context.TODO(), backend, &backend)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 0)
+	})
+
+	mt.Run("parent is set once the ancestors are checked", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: backend}, {Key: "ancestors", Value: bson.A{}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: golang}}}),
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: backend}, {Key: "ancestors", Value: bson.A{}}}),
+		)
+
+		err := tagRepo.SetParent(context.TODO(), golang, &backend)
+		assert.Equal(t, err, nil)
+
+		command := mt.GetAllStartedEvents()[1].Command
+		assert.Equal(t, command.Lookup("query", "_id").ObjectID(), golang)
+		assert.Equal(t, command.Lookup("update", "$set", "parent").ObjectID(), backend)
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 3)
+	})
+
+	mt.Run("a concurrent move making a cycle is moved back", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		previous := models.GenerateObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: backend}, {Key: "ancestors", Value: bson.A{}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: golang}, {Key: "parent", Value: previous}}}),
+			//backend was moved under golang meanwhile
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: backend}, {Key: "ancestors", Value: bson.A{golang}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+
+		err := tagRepo.SetParent(context.TODO(), golang, &backend)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+
+		revert := mt.GetAllStartedEvents()[3].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, revert.Lookup("q", "_id").ObjectID(), golang)
+		assert.Equal(t, revert.Lookup("q", "parent").ObjectID(), backend)
+		assert.Equal(t, revert.Lookup("u", "$set", "parent").ObjectID(), previous)
+	})
+
+	mt.Run("a synonym which names a tag is a conflict", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(1)}}))
+
+		err := tagRepo.AddSynonym(context.TODO(), golang, "Backend")
+		assert.Equal(t, errors.Is(err, domain_errors.ErrConflict), true)
+
+		query := mt.GetAllStartedEvents()[0].Command.Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match").Document()
+		assert.Equal(t, query.Lookup("search_name").StringValue(), "backend")
+		assert.Equal(t, query.Lookup("deleted_at").Type, bsontype.Null)
+	})
+
+	mt.Run("attaching through a synonym tags with its tag", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "acourse.tag_synonyms", mtest.FirstBatch, bson.D{{Key: "synonym", Value: "golang"}, {Key: "tag_id", Value: golang}}),
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch, bson.D{{Key: "_id", Value: golang}, {Key: "name", Value: "Go"}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: golang}, {Key: "name", Value: "Go"}, {Key: "courses", Value: bson.A{courseID}}}}),
+		)
+
+		tag, err := usecase.ConstructTagUsecase(tagRepo, &fakeCourseService{}).AttachCourse(context.TODO(), " golang ", courseID.Hex())
+		assert.Equal(t, err, nil)
+		assert.Equal(t, tag.Name, "Go")
+
+		command := mt.GetAllStartedEvents()[3].Command
+		assert.Equal(t, command.Lookup("query", "_id").ObjectID(), golang)
+		assert.Equal(t, command.Lookup("update", "$addToSet", "courses").ObjectID(), courseID)
+	})
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/controllers"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// catalogueCourseService answers only the courses of its catalogue, like the Course service does
+type catalogueCourseService struct {
+	fakeCourseService
+}
+
+func (f *catalogueCourseService) List(ctx context.Context, coursesID []string) ([]models.Course, error) {
+	courses := make([]models.Course, 0)
+	for _, id := range coursesID {
+		if name, ok := f.names[id]; ok {
+			courses = append(courses, models.Course{ID: models.GenerateObjectIDFromHex(id), Name: name})
+		}
+	}
+	return courses, nil
+}
+
+func TestTagImport(t *testing.T) {
+
+	first, second, unknown := models.GenerateObjectID(), models.GenerateObjectID(), models.GenerateObjectID()
+	courseService := &catalogueCourseService{fakeCourseService{names: map[string]string{first.Hex(): "Go", second.Hex(): "Mongo"}}}
+
+	t.Run("Format_MustBeCSVOrJSONL-", func(t *testing.T) {
+		_, err := usecase.ConstructTagUsecase(nil, courseService).Import(context.TODO(), strings.NewReader(""), "xml")
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+	})
+
+	t.Run("Handler_BodyTooLarge-", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		handler := controllers.TagHandler{TagUsecase: usecase.ConstructTagUsecase(nil, courseService)}
+		router.POST("/internal/tags/import", handler.Import)
+
+		body := first.Hex() + "," + strings.Repeat("x", 4<<20)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/internal/tags/import?format=csv", strings.NewReader(body)))
+
+		assert.Equal(t, recorder.Code, http.StatusRequestEntityTooLarge)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	unresolved := []bson.D{
+		mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch),
+		mtest.CreateCursorResponse(0, "acourse.tag_synonyms", mtest.FirstBatch),
+	}
+
+	mt.Run("csv lines of a tag are one write and bad lines fail alone", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(append(unresolved, mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))...)
+
+		csv := "course_id,tag\n" +
+			first.Hex() + ",Go\n" +
+			second.Hex() + ", Go\n" +
+			"not-an-id,Go\n" +
+			unknown.Hex() + ",Go\n" +
+			first.Hex() + "\n"
+
+		report, err := usecase.ConstructTagUsecase(tagRepo, courseService).Import(context.TODO(), strings.NewReader(csv), "csv")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, report.Applied, 2)
+		assert.Equal(t, report.Failed, 3)
+		assert.Equal(t, report.Lines[0], models.TagImportLine{Line: 2, CourseID: first.Hex(), Tag: "Go", Applied: true})
+		assert.Equal(t, report.Lines[2].Line, 4)
+		assert.Equal(t, report.Lines[3].Error, "course "+unknown.Hex()+" doesn't exist")
+		assert.Equal(t, report.Lines[4].Applied, false)
+
+		events := mt.GetAllStartedEvents()
+		assert.Equal(t, len(events), 3)
+		updates, _ := events[2].Command.Lookup("updates").Array().Values()
+		assert.Equal(t, len(updates), 1)
+		courses, _ := updates[0].Document().Lookup("u", "$addToSet", "courses", "$each").Array().Values()
+		assert.Equal(t, len(courses), 2)
+		assert.Equal(t, updates[0].Document().Lookup("upsert").Boolean(), true)
+	})
+
+	mt.Run("a malformed jsonl line fails alone", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(append(unresolved, mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))...)
+
+		jsonl := `{"course_id": "` + first.Hex() + `", "tag": "Backend"}` + "\n\n" + `{"course_id": 1}` + "\n"
+
+		report, err := usecase.ConstructTagUsecase(tagRepo, courseService).Import(context.TODO(), strings.NewReader(jsonl), "jsonl")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, report.Applied, 1)
+		assert.Equal(t, report.Lines[1].Line, 3)
+		assert.Equal(t, report.Lines[1].Applied, false)
+	})
+
+	mt.Run("a malformed csv record fails alone", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(append(unresolved, mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))...)
+
+		csv := first.Hex() + ",Go\n" +
+			second.Hex() + ",G\"o\n" +
+			second.Hex() + ",Go\n"
+
+		report, err := usecase.ConstructTagUsecase(tagRepo, courseService).Import(context.TODO(), strings.NewReader(csv), "csv")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, report.Applied, 2)
+		assert.Equal(t, report.Failed, 1)
+		assert.Equal(t, report.Lines[1].Line, 2)
+		assert.Equal(t, strings.HasPrefix(report.Lines[1].Error, "line is malformed"), true)
+		assert.Equal(t, report.Lines[2].Line, 3)
+	})
+
+	mt.Run("a jsonl line over the line limit fails alone", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(append(unresolved, mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))...)
+
+		jsonl := `{"course_id": "` + first.Hex() + `", "tag": "` + strings.Repeat("x", 70<<10) + `"}` + "\n" +
+			`{"course_id": "` + second.Hex() + `", "tag": "Backend"}`
+
+		report, err := usecase.ConstructTagUsecase(tagRepo, courseService).Import(context.TODO(), strings.NewReader(jsonl), "jsonl")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, report.Applied, 1)
+		assert.Equal(t, report.Lines[0].Applied, false)
+		assert.Equal(t, report.Lines[1], models.TagImportLine{Line: 2, CourseID: second.Hex(), Tag: "Backend", Applied: true})
+	})
+
+	mt.Run("a tag inserted concurrently is retried", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(append(unresolved,
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)...)
+
+		failures, err := tagRepo.AttachCourses(context.TODO(), []models.TagCourses{{Name: "Go", Courses: []primitive.ObjectID{first}}})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, failures, []error{nil})
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 4)
+	})
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTagSearch(t *testing.T) {
+
+	t.Run("SearchName_FoldsCaseAndAccents+", func(t *testing.T) {
+		assert.Equal(t, models.TagSearchName(" Élixir "), "elixir")
+		assert.Equal(t, models.TagSearchName("Señor Développeur"), "senor developpeur")
+		assert.Equal(t, models.TagSearchName("Go"), "go")
+	})
+
+	t.Run("Query_IsRequired-", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		newTestRouter(nil, nil).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/tag/search?limit=5", nil))
+
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("prefix is folded, quoted and ranked by course count", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: models.GenerateObjectID()}, {Key: "name", Value: "C++"}, {Key: "course_count", Value: int64(4)}},
+			bson.D{{Key: "_id", Value: models.GenerateObjectID()}, {Key: "name", Value: "C++ Templates"}, {Key: "course_count", Value: int64(1)}},
+		))
+
+		tags, err := usecase.ConstructTagUsecase(tagRepo, &fakeCourseService{}).Search(context.TODO(), &requests.TagSearchRequest{Q: "Ç+"})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(tags), 2)
+		assert.Equal(t, tags[0].CourseCount, int64(4))
+
+		pipeline := mt.GetStartedEvent().Command.Lookup("pipeline").Array()
+		assert.Equal(t, pipeline.Index(0).Value().Document().Lookup("$match", "search_name", "$regex").StringValue(), `^c\+`)
+		assert.Equal(t, pipeline.Index(2).Value().Document().Lookup("$sort", "course_count").Int32(), int32(-1))
+		assert.Equal(t, pipeline.Index(3).Value().Document().Lookup("$limit").Int64(), int64(10))
+	})
+
+	mt.Run("a new tag is searchable", func(mt *mtest.T) {
+		tagRepo := repositories.ConstructTagDBRepository(mt.Client.Database("acourse"), mt.Coll, mt.Coll)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "acourse.tags", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "acourse.tag_synonyms", mtest.FirstBatch),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: models.GenerateObjectID()}, {Key: "name", Value: "Élixir"}}}),
+		)
+
+		_, err := tagRepo.AttachCourse(context.TODO(), "Élixir", models.GenerateObjectID())
+		assert.Equal(t, err, nil)
+
+		command := mt.GetAllStartedEvents()[2].Command
+		assert.Equal(t, command.Lookup("update", "$setOnInsert", "search_name").StringValue(), "elixir")
+	})
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/config"
+	"acourse_tag_cart_bookmark_service/pkg/database"
+	"acourse_tag_cart_bookmark_service/pkg/database/migrations"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"acourse_tag_cart_bookmark_service/pkg/usecase"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpsertCourses(t *testing.T) {
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	//the add is stamped after the mock is built, a later 'updated_at' is the document the add wrote
+	upserted := findAndModifyResponse(bson.D{{Key: "version", Value: int64(1)}, {Key: "updated_at", Value: time.Now().Add(time.Minute)}})
+
+	mt.Run("first add upserts the bookmark", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(upserted)
+
+		version, err := bookmarkDBRepo.AddCourse(context.TODO(), "user-1", []string{models.GenerateObjectID().Hex()}, 0)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, version, int64(1))
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, command.Lookup("upsert").Boolean(), true)
+		assert.Equal(t, command.Lookup("new").Boolean(), true)
+		assert.Equal(t, command.Lookup("query", "user_id").StringValue(), "user-1")
+	})
+
+	mt.Run("losing a concurrent insert retries as an update", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11000, Message: "E11000 duplicate key error"}),
+			findAndModifyResponse(bson.D{{Key: "version", Value: int64(2)}}),
+		)
+
+		version, err := cartDBRepo.AddCourse(context.TODO(), "user-1", []models.Course{{ID: models.GenerateObjectID()}}, 0)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, version, int64(2))
+		assert.Equal(t, len(mt.GetAllStartedEvents()), 2)
+	})
+
+	mt.Run("bookmarked courses are a conflict", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(bson.D{{Key: "version", Value: int64(3)}, {Key: "updated_at", Value: time.Now().Add(-time.Minute)}}))
+
+		version, err := bookmarkDBRepo.AddCourse(context.TODO(), "user-1", []string{models.GenerateObjectID().Hex()}, 0)
+
+		assert.Equal(t, errors.Is(err, domain_errors.ErrConflict), true)
+		assert.Equal(t, version, int64(3))
+	})
+
+	mt.Run("no course doesn't create a cart", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(nil))
+
+		version, err := cartDBRepo.AddCourse(context.TODO(), "user-1", []models.Course{}, 0)
+
+		assert.Equal(t, version, int64(0))
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
+
+		assert.Equal(t, mt.GetStartedEvent().Command.Lookup("upsert").Boolean(), false)
+	})
+}
+
+func TestConcurrentFirstAdd(t *testing.T) {
+
+	//Create Config Instance
+	cfg := config.Construct("../../.env")
+
+	//Connecting Databases
+	db := database.Construct(cfg)
+	db.Prepare()
+
+	//Migrations, the unique user_id indexes are what concurrent first adds used to trip on
+	mg := migrations.Construct(db)
+	mg.MigrateSettings()
+
+	bookmarkCollection := db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"])
+	cartCollection := db.GetCollection(cfg.GetDBConfig()["COLLECTION_CARTS"])
+
+	bookmarkUsecase := usecase.ConstructBookmarkUsecase(repositories.ConstructBookmarkDBRepository(db.GetConnection(), bookmarkCollection, 0), nil, nil)
+	cartUsecase := usecase.ConstructCartUsecase(repositories.ConstructCartDBRepository(db.GetConnection(), cartCollection, 0), nil, nil)
+
+	const adds = 16
+	userID := strconv.Itoa(rand.Int())
+	coursesID := make([]string, adds)
+	for i := range coursesID {
+		coursesID[i] = models.GenerateObjectID().Hex()
+	}
+
+	t.Run("ParallelFirstAdds+", func(t *testing.T) {
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 2*adds)
+		for _, courseID := range coursesID {
+			wg.Add(2)
+			go func(courseID string) {
+				defer wg.Done()
+				_, err := bookmarkUsecase.AddCourse(context.TODO(), &requests.AddCourseBookmarkRequest{UserID: userID, Courses: []requests.Course{{ID: courseID}}}, userID)
+				errs <- err
+			}(courseID)
+			go func(courseID string) {
+				defer wg.Done()
+				_, err := cartUsecase.AddCourse(context.TODO(), &requests.AddCourseCartRequest{UserID: userID, Courses: []requests.CartItem{{ID: courseID}}}, userID)
+				errs <- err
+			}(courseID)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.Equal(t, err, nil)
+		}
+
+		bookmarks, err := bookmarkCollection.CountDocuments(context.TODO(), bson.M{"user_id": userID})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmarks, int64(1))
+
+		carts, err := cartCollection.CountDocuments(context.TODO(), bson.M{"user_id": userID})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, carts, int64(1))
+
+		var bookmark models.Bookmark
+		assert.Equal(t, bookmarkCollection.FindOne(context.TODO(), bson.M{"user_id": userID}).Decode(&bookmark), nil)
+		assert.Equal(t, len(bookmark.Courses), adds)
+
+		var cart models.Cart
+		assert.Equal(t, cartCollection.FindOne(context.TODO(), bson.M{"user_id": userID}).Decode(&cart), nil)
+		assert.Equal(t, len(cart.Courses), adds)
+	})
+
+	_, _ = bookmarkCollection.DeleteOne(context.TODO(), bson.M{"user_id": userID})
+	_, _ = cartCollection.DeleteOne(context.TODO(), bson.M{"user_id": userID})
+}
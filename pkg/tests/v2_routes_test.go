@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/client"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestV2Routes(t *testing.T) {
+
+	courseID := models.GenerateObjectID().Hex()
+	bookmarkUsecase := &fakeBookmarkUsecase{bookmark: models.Bookmark{UserID: "user-1"}}
+	cartUsecase := &fakeCartUsecase{cart: models.Cart{UserID: "user-1"}}
+
+	server := httptest.NewServer(newTestRouter(bookmarkUsecase, cartUsecase))
+	defer server.Close()
+
+	c := client.Construct(server.URL)
+	ctx := context.Background()
+
+	t.Run("CreateUserBookmark+", func(t *testing.T) {
+		_, err := c.CreateUserBookmark(ctx, "user-1", []string{courseID})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.CreateBookmarkRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID}}})
+	})
+
+	t.Run("CreateUserBookmark_WithExistingBookmark-", func(t *testing.T) {
+		bookmarkUsecase.err = domain_errors.Conflict("document already exists", nil)
+		defer func() { bookmarkUsecase.err = nil }()
+
+		_, err := c.CreateUserBookmark(ctx, "user-1", []string{courseID})
+		assert.Equal(t, errors.Is(err, domain_errors.ErrConflict), true)
+	})
+
+	t.Run("PutBookmarkItem_IsIdempotent+", func(t *testing.T) {
+		_, err := c.PutBookmarkItem(ctx, "user-1", courseID)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.AddCourseBookmarkRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID}}})
+
+		//the course is already bookmarked
+		bookmarkUsecase.err = domain_errors.Conflict("courses are already bookmarked", nil)
+		defer func() { bookmarkUsecase.err = nil }()
+
+		status, err := c.PutBookmarkItem(ctx, "user-1", courseID)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, status.Status, true)
+	})
+
+	t.Run("DeleteBookmarkItem+", func(t *testing.T) {
+		_, err := c.DeleteBookmarkItem(ctx, "user-1", courseID)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.DeleteAttachedCourseRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID}}})
+	})
+
+	t.Run("CartItems+", func(t *testing.T) {
+		status, err := c.PutCartItem(ctx, "user-1", courseID)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, status.Status, true)
+		assert.Equal(t, cartUsecase.requests[len(cartUsecase.requests)-2:], []interface{}{courseID, requests.PutCartItemRequest{}})
+
+		status, err = c.DeleteCartItem(ctx, "user-1", courseID)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, status.Status, true)
+		assert.Equal(t, cartUsecase.requests[len(cartUsecase.requests)-1], requests.RevokeCourseCartRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID}}})
+	})
+
+	t.Run("PutCartItem_WithInvalidCourseID-", func(t *testing.T) {
+		_, err := c.PutCartItem(ctx, "user-1", "not-an-id")
+		assert.Equal(t, errors.Is(err, domain_errors.ErrInvalidArgument), true)
+	})
+
+	t.Run("Reads_ShareV1Handlers+", func(t *testing.T) {
+		for _, path := range []string{"/v2/users/user-1/bookmarks", "/v2/users/user-1/bookmarks/items", "/v2/users/user-1/cart", "/v2/users/user-1/cart/items", "/v2/bookmarks"} {
+			response, err := http.Get(server.URL + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+			assert.Equal(t, response.StatusCode, http.StatusOK)
+		}
+	})
+
+	t.Run("V1Routes_StillWork+", func(t *testing.T) {
+		_, err := c.AddCartCourses(ctx, "user-1", []string{courseID})
+		assert.Equal(t, err, nil)
+	})
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/client"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"acourse_tag_cart_bookmark_service/pkg/repositories"
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+
+	courseID := models.GenerateObjectID().Hex()
+	bookmarkUsecase := &fakeBookmarkUsecase{bookmark: models.Bookmark{ID: models.GenerateObjectID(), UserID: "user-1", Version: 3}}
+	cartUsecase := &fakeCartUsecase{cart: models.Cart{ID: models.GenerateObjectID(), UserID: "user-1", Version: 7}}
+	router := newTestRouter(bookmarkUsecase, cartUsecase)
+
+	send := func(method string, path string, ifMatch string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			request.Header.Set("If-Match", ifMatch)
+		}
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("ETag_OnReads+", func(t *testing.T) {
+		assert.Equal(t, send(http.MethodGet, "/cart/u/user-1", "", "").Header().Get("ETag"), `"7"`)
+		assert.Equal(t, send(http.MethodGet, "/v2/carts/"+cartUsecase.cart.ID.Hex(), "", "").Header().Get("ETag"), `"7"`)
+		assert.Equal(t, send(http.MethodGet, "/v2/users/user-1/bookmarks", "", "").Header().Get("ETag"), `"3"`)
+	})
+
+	t.Run("ETag_OnChanges+", func(t *testing.T) {
+		assert.Equal(t, send(http.MethodPatch, "/cart/course/add/user-1", "", `{"user_id":"user-1","courses":[{"id":"`+courseID+`"}]}`).Header().Get("ETag"), `"7"`)
+		assert.Equal(t, send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, "", "").Header().Get("ETag"), `"7"`)
+		assert.Equal(t, send(http.MethodPut, "/v2/users/user-1/cart/saved/"+courseID, "", "").Header().Get("ETag"), `"7"`)
+		assert.Equal(t, send(http.MethodDelete, "/v2/users/user-1/bookmarks/items/"+courseID, "", "").Header().Get("ETag"), `"3"`)
+		assert.Equal(t, send(http.MethodPut, "/v2/users/user-1/bookmarks/items/"+courseID+"/tags", "", `{"tags":["revisit"]}`).Header().Get("ETag"), `"3"`)
+
+		created := send(http.MethodPost, "/v2/users/user-1/bookmarks", "", `{"courses":[{"id":"`+courseID+`"}]}`)
+		assert.Equal(t, created.Code, http.StatusCreated)
+		assert.Equal(t, created.Header().Get("ETag"), `"3"`)
+
+		//adding a bookmarked course again changes nothing, the current version is still answered
+		bookmarkUsecase.err = domain_errors.Conflict("courses are already bookmarked", nil)
+		defer func() { bookmarkUsecase.err = nil }()
+		response := send(http.MethodPut, "/v2/users/user-1/bookmarks/items/"+courseID, "", "")
+		assert.Equal(t, response.Code, http.StatusOK)
+		assert.Equal(t, response.Header().Get("ETag"), `"3"`)
+	})
+
+	t.Run("IfMatch_IsTheExpectedVersion+", func(t *testing.T) {
+		response := send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, `"7"`, "")
+		assert.Equal(t, response.Code, http.StatusOK)
+		assert.Equal(t, cartUsecase.requests[len(cartUsecase.requests)-1], requests.PutCartItemRequest{Version: 7})
+
+		response = send(http.MethodPatch, "/bookmark/course/add/user-1", `"3"`, `{"user_id":"user-1","courses":[{"id":"`+courseID+`"}]}`)
+		assert.Equal(t, response.Code, http.StatusOK)
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1], requests.AddCourseBookmarkRequest{UserID: "user-1", Courses: []requests.Course{{ID: courseID}}, Version: 3})
+
+		//without If-Match the write is unconditional
+		send(http.MethodDelete, "/v2/users/user-1/bookmarks/items/"+courseID, "", "")
+		assert.Equal(t, bookmarkUsecase.requests[len(bookmarkUsecase.requests)-1].(requests.DeleteAttachedCourseRequest).Version, int64(0))
+	})
+
+	t.Run("IfMatch_StaleVersion-", func(t *testing.T) {
+		cartUsecase.err = domain_errors.PreconditionFailed("cart has changed since version 6", nil)
+		defer func() { cartUsecase.err = nil }()
+
+		response := send(http.MethodDelete, "/v2/users/user-1/cart/items/"+courseID, `"6"`, "")
+		assert.Equal(t, response.Code, http.StatusPreconditionFailed)
+		assert.Equal(t, strings.Contains(response.Body.String(), `"code":"PRECONDITION_FAILED"`), true)
+	})
+
+	t.Run("IfMatch_UnknownETag-", func(t *testing.T) {
+		handled := len(cartUsecase.requests)
+		response := send(http.MethodPut, "/v2/users/user-1/cart/items/"+courseID, `W/"7"`, "")
+
+		assert.Equal(t, response.Code, http.StatusPreconditionFailed)
+		assert.Equal(t, len(cartUsecase.requests), handled)
+	})
+
+	t.Run("Client_IfMatch+", func(t *testing.T) {
+		server := httptest.NewServer(router)
+		defer server.Close()
+		c := client.Construct(server.URL)
+
+		cart, err := c.GetUserCart(context.Background(), "user-1", models.Projection{})
+		assert.Equal(t, err, nil)
+
+		_, err = c.PutCartItem(client.IfMatch(context.Background(), cart.Version), "user-1", courseID)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, cartUsecase.requests[len(cartUsecase.requests)-1].(requests.PutCartItemRequest).Version, int64(7))
+
+		cartUsecase.err = domain_errors.PreconditionFailed("cart has changed since version 7", nil)
+		defer func() { cartUsecase.err = nil }()
+		_, err = c.DeleteCartItem(client.IfMatch(context.Background(), cart.Version), "user-1", courseID)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrPreconditionFailed), true)
+	})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("expected version filters the update and never upserts", func(mt *mtest.T) {
+		cartDBRepo := repositories.ConstructCartDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(
+			findAndModifyResponse(nil),
+			mtest.CreateCursorResponse(0, "acourse.carts", mtest.FirstBatch, bson.D{{Key: "version", Value: int64(5)}, {Key: "items", Value: int64(1)}}),
+		)
+
+		_, err := cartDBRepo.AddCourse(context.TODO(), "user-1", []models.Course{{ID: models.GenerateObjectIDFromHex(courseID)}}, 4)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrPreconditionFailed), true)
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, command.Lookup("upsert").Boolean(), false)
+		assert.Equal(t, command.Lookup("query", "version").Int64(), int64(4))
+	})
+
+	mt.Run("revoke of a missing document without version is not found", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(findAndModifyResponse(nil))
+
+		_, err := bookmarkDBRepo.RevokeCourse(context.TODO(), "user-1", []string{courseID}, 0)
+		assert.Equal(t, errors.Is(err, domain_errors.ErrNotFound), true)
+	})
+
+	mt.Run("update of a stale bookmark is a precondition failure", func(mt *mtest.T) {
+		bookmarkDBRepo := repositories.ConstructBookmarkDBRepository(mt.Client.Database("acourse"), mt.Coll, 0)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "acourse.bookmarks", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+		)
+
+		bookmark := models.Bookmark{UserID: "user-1", Version: 2}
+		_, err := bookmarkDBRepo.Update(context.TODO(), &bookmark, models.GenerateObjectID().Hex())
+		assert.Equal(t, errors.Is(err, domain_errors.ErrPreconditionFailed), true)
+		assert.Equal(t, bookmark.Version, int64(2))
+	})
+}
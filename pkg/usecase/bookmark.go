@@ -2,84 +2,174 @@ package usecase
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/http/requests"
 	"acourse_tag_cart_bookmark_service/pkg/models"
 	"context"
-	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"log"
+	"strings"
 	"time"
 )
 
 type BookmarkUsecase struct {
 	DBRepository            contracts.BookmarksDBRepository
+	TagDBRepository         contracts.TagDBRepository
 	GRPCCourseServiceClient contracts.GRPCCourseService
 }
 
-func (b BookmarkUsecase) Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, err error) {
-	bookmarks, err = b.DBRepository.Fetch(ctx, exclude, limit, skip)
+func (b BookmarkUsecase) Fetch(ctx context.Context, projection models.Projection, filter *requests.BookmarkFilterRequest, pagination *models.Pagination) (bookmarks []models.Bookmark, err error) {
+
+	bookmarkFilter := models.BookmarkFilter{
+		UserIDPrefix: filter.UserIDPrefix,
+		CreatedFrom:  filter.CreatedFrom,
+		CreatedTo:    filter.CreatedTo,
+		UpdatedFrom:  filter.UpdatedFrom,
+		UpdatedTo:    filter.UpdatedTo,
+		MinCourses:   filter.MinCourses,
+	}
+	if filter.CourseID != "" {
+		bookmarkFilter.CourseID, err = primitive.ObjectIDFromHex(filter.CourseID)
+		if err != nil {
+			return nil, domain_errors.InvalidArgument("invalid course_id", err)
+		}
+	}
+
+	bookmarks, err = b.DBRepository.Fetch(ctx, projection, bookmarkFilter, pagination)
 	if err != nil {
 		return nil, err
 	}
 	return bookmarks, nil
 }
 
-func (b BookmarkUsecase) FetchById(ctx context.Context, bookmarkID string, exclude []string) (bookmark models.Bookmark, err error) {
+func (b BookmarkUsecase) FetchById(ctx context.Context, bookmarkID string, projection models.Projection) (bookmark models.Bookmark, err error) {
 
 	//Fetch Bookmark Containing embedded course id
-	bookmark, err = b.DBRepository.FetchById(ctx, bookmarkID, exclude)
+	bookmark, err = b.DBRepository.FetchById(ctx, bookmarkID, projection)
 	if err != nil {
 		log.Println("BOOKMARK USECASE: FetchById ERROR", err)
 		return models.Bookmark{}, err
 	}
 
-	//Fetch Course Data From CourseService through GRPC
-	cIDs := make([]string, 0)
-	for _, c := range bookmark.Courses {
-		cIDs = append(cIDs, c.ID.Hex())
+	if !projection.Includes("courses") {
+		return bookmark, nil
 	}
 
-	courseResults := b.GRPCCourseServiceClient.List(ctx, cIDs)
-	//log.Println("BOOKMARK USECASE: FETCH BY ID: gRPC CourseService Result >>", courseResults)
-
-	//Attach course data from CourseService to a Bookmark
-	bookmark.Courses = courseResults
+	//Attach course names from CourseService through GRPC, the personal tags are kept
+	bookmark.Courses, err = attachCourseNames(ctx, b.GRPCCourseServiceClient, bookmark.Courses)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
 
-	//log.Println(bookmark)
 	return bookmark, nil
 }
 
-func (b BookmarkUsecase) FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, err error) {
-	bookmark, err = b.DBRepository.FetchByUserId(ctx, userID, exclude)
+func (b BookmarkUsecase) FetchByUserId(ctx context.Context, userID string, projection models.Projection) (bookmark models.Bookmark, err error) {
+	bookmark, err = b.DBRepository.FetchByUserId(ctx, userID, projection)
 	if err != nil {
 		log.Println("BOOKMARK USECASE: FetchByUserId ERROR >>", err)
 		return models.Bookmark{}, err
 	}
 
-	//Fetch Course Data From CourseService through GRPC
-	cIDs := make([]string, 0)
-	for _, c := range bookmark.Courses {
-		cIDs = append(cIDs, c.ID.Hex())
+	if !projection.Includes("courses") {
+		return bookmark, nil
 	}
 
-	//Attach course data from CourseService to a Bookmark
-	courseResults := b.GRPCCourseServiceClient.List(ctx, cIDs)
-	bookmark.Courses = courseResults
+	//Attach course names from CourseService through GRPC, the personal tags are kept
+	bookmark.Courses, err = attachCourseNames(ctx, b.GRPCCourseServiceClient, bookmark.Courses)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
 
 	return bookmark, nil
 }
 
+func (b BookmarkUsecase) FetchByUserTag(ctx context.Context, userID string, tag string, projection models.Projection) (bookmark models.Bookmark, err error) {
+
+	tag, err = personalTag(tag)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
+
+	bookmark, err = b.DBRepository.FetchByUserTag(ctx, userID, tag, projection)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: FetchByUserTag ERROR >>", err)
+		return models.Bookmark{}, err
+	}
+
+	if !projection.Includes("courses") {
+		return bookmark, nil
+	}
+
+	bookmark.Courses, err = attachCourseNames(ctx, b.GRPCCourseServiceClient, bookmark.Courses)
+	if err != nil {
+		return models.Bookmark{}, err
+	}
+
+	return bookmark, nil
+}
+
+func (b BookmarkUsecase) SetCourseTags(ctx context.Context, userID string, courseID string, request *requests.CourseTagsRequest) (version int64, err error) {
+
+	if !primitive.IsValidObjectID(courseID) {
+		return 0, domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
+	}
+
+	//Tags are matched as stored, they are lower cased and listed once
+	tags := make([]string, 0, len(request.Tags))
+	seen := make(map[string]bool)
+	for _, raw := range request.Tags {
+		tag, err := personalTag(raw)
+		if err != nil {
+			return 0, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	version, err = b.DBRepository.SetCourseTags(ctx, userID, models.GenerateObjectIDFromHex(courseID), tags, request.Version)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: SetCourseTags ERROR >>", err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// personalTag normalize a personal tag the way it is stored
+func personalTag(raw string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(raw))
+	if tag == "" || len(tag) > 32 {
+		return "", domain_errors.InvalidArgument(fmt.Sprintf("tag %q must be between 1 and 32 characters", raw), nil)
+	}
+	return tag, nil
+}
+
+func (b BookmarkUsecase) FetchCourses(ctx context.Context, userID string, request *requests.CourseListRequest) (courses []models.Course, pagination models.Pagination, err error) {
+	courses, pagination, err = listCourses(ctx, b.DBRepository.FetchCourses, b.TagDBRepository, b.GRPCCourseServiceClient, userID, request)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: FetchCourses ERROR >>", err)
+		return nil, models.Pagination{}, err
+	}
+	return courses, pagination, nil
+}
+
 func (b BookmarkUsecase) Create(ctx context.Context, request *requests.CreateBookmarkRequest) (bookmark models.Bookmark, err error) {
 
+	timeNow := time.Now()
 	courses := make([]models.Course, 0)
 	for _, course := range request.Courses {
-		courses = append(courses, models.Course{ID: b.DBRepository.GenerateObjectIDFromString(course.ID)})
+		courses = append(courses, models.Course{ID: b.DBRepository.GenerateObjectIDFromString(course.ID), AddedAt: &timeNow})
 	}
 
-	timeNow := time.Now()
 	newBookmark := models.Bookmark{
 		ID:        b.DBRepository.GenerateModelID(),
 		UserID:    request.UserID,
 		Courses:   courses,
+		Version:   1,
 		UpdatedAt: &timeNow,
 		CreatedAt: &timeNow,
 	}
@@ -93,52 +183,37 @@ func (b BookmarkUsecase) Create(ctx context.Context, request *requests.CreateBoo
 	return newBookmark, nil
 }
 
-func (b BookmarkUsecase) AddCourse(ctx context.Context, request *requests.AddCourseBookmarkRequest, userID string) (status bool, err error) {
-
-	//if a bookmark not found, then create a new one
-	_, err = b.FetchByUserId(ctx, userID, []string{})
-	if err != nil {
-		if err.Error() == errors.New("mongo: no documents in result").Error() {
-			_, err = b.Create(ctx, (*requests.CreateBookmarkRequest)(request))
-			if err != nil {
-				log.Println("BOOKMARK USECASE: AddCourse >>", err)
-				return false, err
-			}
-			log.Println("BOOKMARK USECASE: AddCourse >>", "Course has been added", request.Courses)
-			return true, nil
-		}
-		log.Println("BOOKMARK USECASE: AddCourse >>", err)
-		return false, err
-	}
+func (b BookmarkUsecase) AddCourse(ctx context.Context, request *requests.AddCourseBookmarkRequest, userID string) (version int64, err error) {
 
 	cID := make([]string, 0)
 	for _, course := range request.Courses {
 		cID = append(cID, course.ID)
 	}
 
-	status, err = b.DBRepository.AddCourse(ctx, userID, cID)
+	//the bookmark is created by the first add, a conflict keeps the current version
+	version, err = b.DBRepository.AddCourse(ctx, userID, cID, request.Version)
 	if err != nil {
 		log.Println("BOOKMARK USECASE: AddCourse >>", err)
-		return false, err
+		return version, err
 	}
 
-	return status, nil
+	return version, nil
 }
 
-func (b BookmarkUsecase) RevokeCourse(ctx context.Context, request *requests.DeleteAttachedCourseRequest, userID string) (status bool, err error) {
+func (b BookmarkUsecase) RevokeCourse(ctx context.Context, request *requests.DeleteAttachedCourseRequest, userID string) (version int64, err error) {
 
 	coursesID := make([]string, 0)
 	for _, c := range request.Courses {
 		coursesID = append(coursesID, c.ID)
 	}
 
-	status, err = b.DBRepository.RevokeCourse(ctx, userID, coursesID)
+	version, err = b.DBRepository.RevokeCourse(ctx, userID, coursesID, request.Version)
 	if err != nil {
 		log.Println("BOOKMARK USECASE REVOKE COURSE:", err.Error())
-		return false, err
+		return 0, err
 	}
 
-	return status, nil
+	return version, nil
 }
 
 func (b BookmarkUsecase) Delete(ctx context.Context, bookmarkID string) (status bool, err error) {
@@ -149,6 +224,6 @@ func (b BookmarkUsecase) Delete(ctx context.Context, bookmarkID string) (status
 	return status, nil
 }
 
-func ConstructBookmarkUsecase(DBRepository contracts.BookmarksDBRepository, GRPCCourseServiceClient contracts.GRPCClient) contracts.BookmarkUsecase {
-	return &BookmarkUsecase{DBRepository: DBRepository, GRPCCourseServiceClient: GRPCCourseServiceClient}
+func ConstructBookmarkUsecase(DBRepository contracts.BookmarksDBRepository, TagDBRepository contracts.TagDBRepository, GRPCCourseServiceClient contracts.GRPCClient) contracts.BookmarkUsecase {
+	return &BookmarkUsecase{DBRepository: DBRepository, TagDBRepository: TagDBRepository, GRPCCourseServiceClient: GRPCCourseServiceClient}
 }
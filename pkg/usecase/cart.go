@@ -2,138 +2,232 @@ package usecase
 
 import (
 	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
 	"acourse_tag_cart_bookmark_service/pkg/http/requests"
 	"acourse_tag_cart_bookmark_service/pkg/models"
 	"context"
-	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"log"
+	"strings"
 )
 
-var ErrNoDocuments = errors.New("mongo: no documents in result")
-
 type CartUsecase struct {
 	DBRepository            contracts.CartDBRepository
+	TagDBRepository         contracts.TagDBRepository
 	GRPCCourseServiceClient contracts.GRPCCourseService
 }
 
-func (c CartUsecase) FetchById(ctx context.Context, id string, exclude []string) (models.Cart, error) {
+func (c CartUsecase) FetchById(ctx context.Context, id string, projection models.Projection) (models.Cart, error) {
 
 	//Fetch a Cart
-	cart, err := c.DBRepository.FetchById(ctx, id, exclude)
+	cart, err := c.DBRepository.FetchById(ctx, id, projection)
 	if err != nil {
 		return models.Cart{}, err
 	}
 
-	//Fetch Course Data From CourseService through GRPC
-	cIDs := make([]string, 0)
-	for _, c := range cart.Courses {
-		cIDs = append(cIDs, c.ID.Hex())
+	//Attach course names from CourseService through GRPC, the seats of the cart are kept
+	err = c.attachCartNames(ctx, &cart, projection)
+	if err != nil {
+		return models.Cart{}, err
 	}
 
-	courseResults := c.GRPCCourseServiceClient.List(ctx, cIDs)
-	//log.Println("BOOKMARK USECASE: FETCH BY ID: gRPC CourseService Result >>", courseResults)
-
-	//Attach course data from CourseService to a Bookmark
-	cart.Courses = courseResults
-
 	return cart, nil
 
 }
 
-func (c CartUsecase) FetchByUserId(ctx context.Context, userID string, exclude []string) (models.Cart, error) {
+func (c CartUsecase) FetchByUserId(ctx context.Context, userID string, projection models.Projection) (models.Cart, error) {
 
 	//Fetch a Cart
-	cart, err := c.DBRepository.FetchByUserId(ctx, userID, exclude)
+	cart, err := c.DBRepository.FetchByUserId(ctx, userID, projection)
 	if err != nil {
 		return models.Cart{}, err
 	}
 
-	//Fetch Course Data From CourseService through GRPC
-	cIDs := make([]string, 0)
-	for _, c := range cart.Courses {
-		cIDs = append(cIDs, c.ID.Hex())
+	//Attach course names from CourseService through GRPC, the seats of the cart are kept
+	err = c.attachCartNames(ctx, &cart, projection)
+	if err != nil {
+		return models.Cart{}, err
 	}
 
-	courseResults := c.GRPCCourseServiceClient.List(ctx, cIDs)
-	//log.Println("BOOKMARK USECASE: FETCH BY ID: gRPC CourseService Result >>", courseResults)
+	return cart, nil
+}
 
-	//Attach course data from CourseService to a Bookmark
-	cart.Courses = courseResults
+// attachCartNames attach the course names to the courses and the courses saved for later selected by the projection
+func (c CartUsecase) attachCartNames(ctx context.Context, cart *models.Cart, projection models.Projection) (err error) {
 
-	return cart, nil
+	if projection.Includes("courses") {
+		cart.Courses, err = attachCourseNames(ctx, c.GRPCCourseServiceClient, cart.Courses)
+		if err != nil {
+			return err
+		}
+	}
+
+	if projection.Includes("saved_courses") {
+		cart.SavedCourses, err = attachCourseNames(ctx, c.GRPCCourseServiceClient, cart.SavedCourses)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (c CartUsecase) AddCourse(ctx context.Context, request *requests.AddCourseCartRequest, userID string) (status bool, err error) {
+func (c CartUsecase) FetchCourses(ctx context.Context, userID string, request *requests.CourseListRequest) (courses []models.Course, pagination models.Pagination, err error) {
+	courses, pagination, err = listCourses(ctx, c.DBRepository.FetchCourses, c.TagDBRepository, c.GRPCCourseServiceClient, userID, request)
+	if err != nil {
+		log.Println("CART USECASE: FetchCourses >>", err)
+		return nil, models.Pagination{}, err
+	}
+	return courses, pagination, nil
+}
+
+func (c CartUsecase) AddCourse(ctx context.Context, request *requests.AddCourseCartRequest, userID string) (version int64, err error) {
 
 	if len(request.Courses) == 0 {
-		return false, errors.New("you don't provide any course id, added nothing")
+		return 0, domain_errors.InvalidArgument("you don't provide any course id, added nothing", nil)
 	}
 
-	_, err = c.DBRepository.FetchByUserId(ctx, userID, []string{})
+	//Course ids which aren't valid are skipped
+	courses := make([]models.Course, 0)
+	for _, item := range request.Courses {
+		if !primitive.IsValidObjectID(item.ID) {
+			continue
+		}
+		course, err := cartItem(item.ID, item.Quantity, item.Recipients)
+		if err != nil {
+			return 0, err
+		}
+		courses = append(courses, course)
+	}
+
+	//the cart is created by the first add
+	version, err = c.DBRepository.AddCourse(ctx, userID, courses, request.Version)
 	if err != nil {
+		log.Println("CART USECASE: AddCourse: Add Courses >>", err)
+		return 0, err
+	}
 
-		//Create a cart if it doesn't exist yet
-		cIDs := make([]models.Course, 0)
-		for _, course := range request.Courses {
-			cIDs = append(cIDs, models.Course{ID: models.GenerateObjectIDFromHex(course.ID)})
-		}
+	return version, nil
+}
+
+func (c CartUsecase) PutCourse(ctx context.Context, userID string, courseID string, request *requests.PutCartItemRequest) (version int64, err error) {
+
+	if !primitive.IsValidObjectID(courseID) {
+		return 0, domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
+	}
+
+	course, err := cartItem(courseID, request.Quantity, request.Recipients)
+	if err != nil {
+		return 0, err
+	}
 
-		if err.Error() == ErrNoDocuments.Error() {
+	version, err = c.DBRepository.PutCourse(ctx, userID, course, request.Version)
+	if err != nil {
+		log.Println("CART USECASE: PutCourse >>", err)
+		return 0, err
+	}
 
-			_, err := c.DBRepository.Create(ctx, &models.Cart{
-				ID:      models.GenerateObjectID(),
-				UserID:  userID,
-				Courses: cIDs,
-			})
+	return version, nil
+}
 
-			if err != nil {
-				log.Println("CART USECASE: AddCourse: Create Cart >>", err)
-				return false, err
-			}
+// cartItem validate the seats of a course, a gift has one recipient per seat and every recipient once
+func cartItem(courseID string, quantity int64, recipients []string) (models.Course, error) {
 
-			return true, nil
+	course := models.Course{ID: models.GenerateObjectIDFromHex(courseID), Quantity: quantity}
+	if len(recipients) == 0 {
+		return course, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, recipient := range recipients {
+		email := strings.ToLower(strings.TrimSpace(recipient))
+		if seen[email] {
+			return models.Course{}, domain_errors.InvalidArgument(fmt.Sprintf("recipient %q is listed twice", recipient), nil)
 		}
+		seen[email] = true
+		course.Recipients = append(course.Recipients, email)
+	}
 
-		log.Println("CART USECASE: AddCourse: Create Cart >>", err)
-		return false, err
+	if quantity == 0 {
+		course.Quantity = int64(len(course.Recipients))
 	}
+	if course.Quantity != int64(len(course.Recipients)) {
+		return models.Course{}, domain_errors.InvalidArgument(fmt.Sprintf("a gift of %d seats needs %d recipients, got %d", course.Quantity, course.Quantity, len(course.Recipients)), nil)
+	}
+
+	return course, nil
+}
+
+func (c CartUsecase) RevokeCourse(ctx context.Context, request *requests.RevokeCourseCartRequest, userID string) (int64, error) {
 
-	//if it does exist then add course_id to existing cart
 	cIDs := make([]string, 0)
+	if len(request.Courses) == 0 {
+		return 0, domain_errors.InvalidArgument("you don't provide any course id, nothing removed", nil)
+	}
+
 	for _, course := range request.Courses {
 		cIDs = append(cIDs, course.ID)
 	}
 
-	status, err = c.DBRepository.AddCourse(ctx, userID, cIDs)
+	version, err := c.DBRepository.RevokeCourse(ctx, userID, cIDs, request.Version)
 	if err != nil {
-		log.Println("CART USECASE: AddCourse: Add Courses >>", err)
-		return false, err
+		return 0, err
 	}
 
-	return true, nil
-	//c.DBRepository.AddCourse(ctx, userID)
+	return version, nil
+
 }
 
-func (c CartUsecase) RevokeCourse(ctx context.Context, request *requests.RevokeCourseCartRequest, userID string) (bool, error) {
+func (c CartUsecase) Summary(ctx context.Context, userID string) (models.CartSummary, error) {
 
-	cIDs := make([]string, 0)
-	if len(request.Courses) == 0 {
-		return false, errors.New("you don't provide any course id, nothing removed")
+	cart, err := c.DBRepository.FetchByUserId(ctx, userID, models.Projection{Fields: []string{"courses", "saved_courses"}})
+	if err != nil {
+		log.Println("CART USECASE: Summary >>", err)
+		return models.CartSummary{}, err
 	}
 
-	for _, course := range request.Courses {
-		cIDs = append(cIDs, course.ID)
+	return cart.Summary(), nil
+}
+
+func (c CartUsecase) SaveForLater(ctx context.Context, userID string, courseID string, version int64) (int64, error) {
+	return c.moveCourse(ctx, userID, courseID, true, version)
+}
+
+func (c CartUsecase) MoveToCart(ctx context.Context, userID string, courseID string, version int64) (int64, error) {
+	return c.moveCourse(ctx, userID, courseID, false, version)
+}
+
+func (c CartUsecase) moveCourse(ctx context.Context, userID string, courseID string, saved bool, version int64) (int64, error) {
+
+	if !primitive.IsValidObjectID(courseID) {
+		return 0, domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
 	}
 
-	status, err := c.DBRepository.RevokeCourse(ctx, userID, cIDs)
+	newVersion, err := c.DBRepository.MoveCourse(ctx, userID, models.GenerateObjectIDFromHex(courseID), saved, version)
 	if err != nil {
-		return false, err
+		log.Println("CART USECASE: MoveCourse >>", err)
+		return 0, err
+	}
+
+	return newVersion, nil
+}
+
+func (c CartUsecase) RemoveSaved(ctx context.Context, userID string, courseID string, version int64) (int64, error) {
+
+	if !primitive.IsValidObjectID(courseID) {
+		return 0, domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
 	}
 
-	return status, nil
+	newVersion, err := c.DBRepository.RevokeSavedCourse(ctx, userID, []primitive.ObjectID{models.GenerateObjectIDFromHex(courseID)}, version)
+	if err != nil {
+		log.Println("CART USECASE: RemoveSaved >>", err)
+		return 0, err
+	}
 
+	return newVersion, nil
 }
 
-func ConstructCartUsecase(DBRepository contracts.CartDBRepository, grpcCourseService contracts.GRPCCourseService) contracts.CartUsecase {
-	return &CartUsecase{DBRepository: DBRepository, GRPCCourseServiceClient: grpcCourseService}
+func ConstructCartUsecase(DBRepository contracts.CartDBRepository, tagDBRepository contracts.TagDBRepository, grpcCourseService contracts.GRPCCourseService) contracts.CartUsecase {
+	return &CartUsecase{DBRepository: DBRepository, TagDBRepository: tagDBRepository, GRPCCourseServiceClient: grpcCourseService}
 }
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+// defaultNotifyTimeout bounds the notifications of a purge, the purge is answered once it passes
+const defaultNotifyTimeout = 10 * time.Second
+
+type CourseUsecase struct {
+	BookmarkDBRepository contracts.BookmarksDBRepository
+	CartDBRepository     contracts.CartDBRepository
+	// Notifier is optional, affected users are not notified when it is nil
+	Notifier contracts.Notifier
+	// NotifyTimeout bounds the notifications of a purge, zero is defaultNotifyTimeout
+	NotifyTimeout time.Duration
+}
+
+func (c CourseUsecase) PurgeCourse(ctx context.Context, courseID string) (purge models.CoursePurge, err error) {
+
+	objectID, err := primitive.ObjectIDFromHex(courseID)
+	if err != nil {
+		return models.CoursePurge{}, domain_errors.InvalidArgument("invalid course_id", err)
+	}
+
+	purge = models.CoursePurge{CourseID: objectID}
+
+	//1. Pull the course from bookmarks and carts
+	bookmarkUsers, bookmarksModified, err := c.BookmarkDBRepository.PurgeCourse(ctx, objectID)
+	if err != nil {
+		log.Println("COURSE USECASE: PurgeCourse: Bookmarks >>", err)
+		return models.CoursePurge{}, err
+	}
+	purge.BookmarksModified = bookmarksModified
+
+	cartUsers, cartsModified, err := c.CartDBRepository.PurgeCourse(ctx, objectID)
+	if err != nil {
+		log.Println("COURSE USECASE: PurgeCourse: Carts >>", err)
+		return models.CoursePurge{}, err
+	}
+	purge.CartsModified = cartsModified
+	purge.PurgedAt = time.Now()
+
+	log.Println("COURSE USECASE: PurgeCourse >>", courseID, "bookmarks modified:", bookmarksModified, "carts modified:", cartsModified)
+
+	//2. Notify affected users, a failed notification doesn't undo the purge;
+	//the ones left when the timeout passes are only logged
+	if c.Notifier == nil {
+		return purge, nil
+	}
+
+	timeout := c.NotifyTimeout
+	if timeout <= 0 {
+		timeout = defaultNotifyTimeout
+	}
+	notifyCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for source, usersID := range map[string][]string{models.StatisticSourceBookmarks: bookmarkUsers, models.StatisticSourceCarts: cartUsers} {
+		for i, userID := range usersID {
+			if notifyCtx.Err() != nil {
+				log.Println("COURSE USECASE: PurgeCourse: Notify >>", len(usersID)-i, source, "users not notified:", notifyCtx.Err())
+				break
+			}
+			err := c.Notifier.NotifyCoursePurged(notifyCtx, userID, courseID, source)
+			if err != nil {
+				log.Println("COURSE USECASE: PurgeCourse: Notify >>", userID, err)
+				continue
+			}
+			purge.NotificationsSent++
+		}
+	}
+
+	return purge, nil
+}
+
+func ConstructCourseUsecase(bookmarkDBRepository contracts.BookmarksDBRepository, cartDBRepository contracts.CartDBRepository, notifier contracts.Notifier) contracts.CourseUsecase {
+	return &CourseUsecase{BookmarkDBRepository: bookmarkDBRepository, CartDBRepository: cartDBRepository, Notifier: notifier}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+)
+
+const defaultCoursesPerPage = 25
+
+// maxNameOrderedCourses bounds the list hydrated in one Course service call to sort or filter by name
+const maxNameOrderedCourses = 1000
+
+// courseFetcher is the FetchCourses method of the bookmark or the cart repository
+type courseFetcher func(ctx context.Context, userID string, coursesID []primitive.ObjectID, descending bool, limit int64, skip int64) ([]models.Course, int64, error)
+
+// listCourses select a page of the courses embedded in a bookmark or a cart
+func listCourses(ctx context.Context, fetch courseFetcher, tagRepository contracts.TagDBRepository, courseService contracts.GRPCCourseService, userID string, request *requests.CourseListRequest) ([]models.Course, models.Pagination, error) {
+
+	pagination := models.Pagination{Page: request.Page, PerPage: request.PerPage}
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.PerPage < 1 {
+		pagination.PerPage = defaultCoursesPerPage
+	}
+	limit, skip := pagination.GetPagination()
+
+	//1. A tag narrows down the courses to the ones carrying it
+	var coursesID []primitive.ObjectID
+	if request.Tag != "" {
+		tagged, err := tagRepository.FetchCoursesByTag(ctx, request.Tag)
+		if err != nil {
+			return nil, models.Pagination{}, err
+		}
+		coursesID = tagged
+	}
+
+	descending := strings.HasPrefix(request.Sort, "-")
+	byName := strings.TrimPrefix(request.Sort, "-") == "name"
+
+	//2. Sorted by added date, only the requested page is fetched and hydrated
+	if !byName && request.Name == "" {
+		courses, total, err := fetch(ctx, userID, coursesID, descending, limit, skip)
+		if err != nil {
+			return nil, models.Pagination{}, err
+		}
+
+		pagination.Total = total
+		pagination.HasNext = skip+int64(len(courses)) < total
+
+		courses, err = attachCourseNames(ctx, courseService, courses)
+		if err != nil {
+			return nil, models.Pagination{}, err
+		}
+
+		return courses, pagination, nil
+	}
+
+	//3. Names are only known by the Course service, so every course is hydrated before filtering or sorting by name;
+	//a longer list is refused rather than hydrated without bound
+	courses, total, err := fetch(ctx, userID, coursesID, descending && !byName, maxNameOrderedCourses, 0)
+	if err != nil {
+		return nil, models.Pagination{}, err
+	}
+	if total > maxNameOrderedCourses {
+		return nil, models.Pagination{}, domain_errors.InvalidArgument(fmt.Sprintf("sorting or filtering by name is limited to lists of at most %d courses, this one has %d", maxNameOrderedCourses, total), nil)
+	}
+	courses, err = attachCourseNames(ctx, courseService, courses)
+	if err != nil {
+		return nil, models.Pagination{}, err
+	}
+
+	if request.Name != "" {
+		name := strings.ToLower(request.Name)
+		matched := make([]models.Course, 0)
+		for _, course := range courses {
+			if strings.Contains(strings.ToLower(course.Name), name) {
+				matched = append(matched, course)
+			}
+		}
+		courses = matched
+	}
+
+	if byName {
+		sort.SliceStable(courses, func(i, j int) bool {
+			if descending {
+				return strings.ToLower(courses[i].Name) > strings.ToLower(courses[j].Name)
+			}
+			return strings.ToLower(courses[i].Name) < strings.ToLower(courses[j].Name)
+		})
+	}
+
+	pagination.Total = int64(len(courses))
+	pagination.HasNext = skip+limit < pagination.Total
+
+	if skip >= pagination.Total {
+		return []models.Course{}, pagination, nil
+	}
+	if skip+limit > pagination.Total {
+		limit = pagination.Total - skip
+	}
+
+	return courses[skip : skip+limit], pagination, nil
+}
+
+// attachCourseNames fetch course data from CourseService through GRPC,
+// the stored order and added date of the courses are kept
+func attachCourseNames(ctx context.Context, courseService contracts.GRPCCourseService, courses []models.Course) ([]models.Course, error) {
+
+	if len(courses) == 0 {
+		return courses, nil
+	}
+
+	cIDs := make([]string, 0)
+	for _, course := range courses {
+		cIDs = append(cIDs, course.ID.Hex())
+	}
+
+	results, err := courseService.List(ctx, cIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[primitive.ObjectID]string)
+	for _, course := range results {
+		names[course.ID] = course.Name
+	}
+
+	for i := range courses {
+		courses[i].Name = names[courses[i].ID]
+	}
+
+	return courses, nil
+}
@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+type ExportUsecase struct {
+	BookmarkUsecase            contracts.BookmarkUsecase
+	CartUsecase                contracts.CartUsecase
+	RecentlyViewedUsecase      contracts.RecentlyViewedUsecase
+	RecommendationDBRepository contracts.RecommendationDBRepository
+	IdempotencyDBRepository    contracts.IdempotencyDBRepository
+	GRPCCourseServiceClient    contracts.GRPCCourseService
+}
+
+// exportSection is a part of the export, a nil value means this service stores nothing of that kind about the user
+type exportSection struct {
+	name  string
+	fetch func(ctx context.Context, userID string) (interface{}, error)
+}
+
+func (e ExportUsecase) Export(ctx context.Context, userID string, format string, w io.Writer) error {
+
+	var writer exportWriter
+	switch format {
+	case "json":
+		writer = &jsonExportWriter{w: w}
+	case "zip":
+		writer = &zipExportWriter{w: w}
+	default:
+		return domain_errors.InvalidArgument(fmt.Sprintf("format %q must be json or zip", format), nil)
+	}
+
+	//Personal tags are embedded in the bookmark and courses saved for later in the cart
+	sections := []exportSection{
+		{"bookmark", e.bookmark},
+		{"cart", e.cart},
+		{"recently_viewed", e.recentlyViewed},
+		{"recommendations", e.recommendations},
+		{"idempotency_records", e.idempotencyRecords},
+	}
+
+	names := make([]string, 0, len(sections))
+	for _, section := range sections {
+		names = append(names, section.name)
+	}
+	header := models.UserExport{UserID: userID, ExportedAt: time.Now(), Sections: names}
+
+	//Each section is fetched then written, only one is held in memory at a time
+	for _, section := range sections {
+		value, err := section.fetch(ctx, userID)
+		if err != nil {
+			log.Println("EXPORT USECASE: Export:", section.name, ">>", err)
+			return err
+		}
+
+		err = writer.section(header, section.name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.close()
+}
+
+func (e ExportUsecase) bookmark(ctx context.Context, userID string) (interface{}, error) {
+	bookmark, err := e.BookmarkUsecase.FetchByUserId(ctx, userID, models.Projection{})
+	if errors.Is(err, domain_errors.ErrNotFound) {
+		return nil, nil
+	}
+	return bookmark, err
+}
+
+func (e ExportUsecase) cart(ctx context.Context, userID string) (interface{}, error) {
+	cart, err := e.CartUsecase.FetchByUserId(ctx, userID, models.Projection{})
+	if errors.Is(err, domain_errors.ErrNotFound) {
+		return nil, nil
+	}
+	return cart, err
+}
+
+func (e ExportUsecase) recentlyViewed(ctx context.Context, userID string) (interface{}, error) {
+	return e.RecentlyViewedUsecase.Fetch(ctx, userID, &requests.RecentlyViewedRequest{})
+}
+
+func (e ExportUsecase) recommendations(ctx context.Context, userID string) (interface{}, error) {
+
+	recommendation, err := e.RecommendationDBRepository.FetchCached(ctx, userID)
+	if errors.Is(err, domain_errors.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//Names aren't cached, they are attached like for the recommendations route
+	courses := make([]models.Course, 0, len(recommendation.Courses))
+	for _, recommended := range recommendation.Courses {
+		courses = append(courses, models.Course{ID: recommended.ID})
+	}
+
+	courses, err = attachCourseNames(ctx, e.GRPCCourseServiceClient, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range recommendation.Courses {
+		recommendation.Courses[i].Name = courses[i].Name
+	}
+
+	return recommendation, nil
+}
+
+func (e ExportUsecase) idempotencyRecords(ctx context.Context, userID string) (interface{}, error) {
+	return e.IdempotencyDBRepository.FetchByUserId(ctx, userID)
+}
+
+// exportWriter writes the sections of an export as they come, the header goes with the first section
+type exportWriter interface {
+	section(header models.UserExport, name string, value interface{}) error
+	close() error
+}
+
+// jsonExportWriter writes a single object, the header fields then one field per section
+type jsonExportWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonExportWriter) section(header models.UserExport, name string, value interface{}) error {
+
+	if !j.started {
+		opening, err := json.Marshal(header)
+		if err != nil {
+			return err
+		}
+		//The header object is left open for the sections
+		_, err = j.w.Write(opening[:len(opening)-1])
+		if err != nil {
+			return err
+		}
+		j.started = true
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	key, _ := json.Marshal(name)
+	_, err = fmt.Fprintf(j.w, ",%s:%s", key, encoded)
+	return err
+}
+
+func (j *jsonExportWriter) close() error {
+	_, err := j.w.Write([]byte("}\n"))
+	return err
+}
+
+// zipExportWriter writes export.json with the header, then one '<section>.json' file per section
+type zipExportWriter struct {
+	w       io.Writer
+	archive *zip.Writer
+}
+
+func (z *zipExportWriter) section(header models.UserExport, name string, value interface{}) error {
+
+	if z.archive == nil {
+		z.archive = zip.NewWriter(z.w)
+		err := z.file("export.json", header)
+		if err != nil {
+			return err
+		}
+	}
+
+	return z.file(name+".json", value)
+}
+
+func (z *zipExportWriter) file(name string, value interface{}) error {
+
+	file, err := z.archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (z *zipExportWriter) close() error {
+	return z.archive.Close()
+}
+
+func ConstructExportUsecase(bookmarkUsecase contracts.BookmarkUsecase, cartUsecase contracts.CartUsecase, recentlyViewedUsecase contracts.RecentlyViewedUsecase, recommendationDBRepository contracts.RecommendationDBRepository, idempotencyDBRepository contracts.IdempotencyDBRepository, grpcCourseService contracts.GRPCCourseService) contracts.ExportUsecase {
+	return &ExportUsecase{
+		BookmarkUsecase:            bookmarkUsecase,
+		CartUsecase:                cartUsecase,
+		RecentlyViewedUsecase:      recentlyViewedUsecase,
+		RecommendationDBRepository: recommendationDBRepository,
+		IdempotencyDBRepository:    idempotencyDBRepository,
+		GRPCCourseServiceClient:    grpcCourseService,
+	}
+}
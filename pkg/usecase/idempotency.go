@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+type IdempotencyUsecase struct {
+	DBRepository contracts.IdempotencyDBRepository
+	// TTL is how long a response is replayed, the TTL index removes expired records
+	TTL time.Duration
+}
+
+func (i IdempotencyUsecase) Begin(ctx context.Context, userID string, key string, fingerprint string) (replay *models.IdempotencyRecord, err error) {
+
+	//1. Reserve the key, the first request wins
+	now := time.Now()
+	err = i.DBRepository.Create(ctx, &models.IdempotencyRecord{
+		ID:          models.GenerateObjectID(),
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.TTL),
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, domain_errors.ErrConflict) {
+		log.Println("IDEMPOTENCY USECASE: Begin >>", err)
+		return nil, err
+	}
+
+	//2. The key is taken, replay its response when it belongs to the same request
+	record, err := i.DBRepository.FetchByKey(ctx, userID, key)
+	if err != nil {
+		log.Println("IDEMPOTENCY USECASE: Begin: FetchByKey >>", err)
+		return nil, err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, domain_errors.Conflict("idempotency key was already used with a different request", nil)
+	}
+
+	if record.Response == nil {
+		return nil, domain_errors.Conflict("a request with this idempotency key is still in progress", nil)
+	}
+
+	return &record, nil
+}
+
+func (i IdempotencyUsecase) Complete(ctx context.Context, userID string, key string, response models.IdempotentResponse) error {
+	err := i.DBRepository.Complete(ctx, userID, key, response)
+	if err != nil {
+		log.Println("IDEMPOTENCY USECASE: Complete >>", err)
+		return err
+	}
+	return nil
+}
+
+func (i IdempotencyUsecase) Abort(ctx context.Context, userID string, key string) error {
+	err := i.DBRepository.Delete(ctx, userID, key)
+	if err != nil {
+		log.Println("IDEMPOTENCY USECASE: Abort >>", err)
+		return err
+	}
+	return nil
+}
+
+// ConstructIdempotencyUsecase a zero 'ttl' replays responses for a day
+func ConstructIdempotencyUsecase(DBRepository contracts.IdempotencyDBRepository, ttl time.Duration) contracts.IdempotencyUsecase {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return &IdempotencyUsecase{DBRepository: DBRepository, TTL: ttl}
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+type RecentlyViewedUsecase struct {
+	DBRepository            contracts.RecentlyViewedDBRepository
+	GRPCCourseServiceClient contracts.GRPCCourseService
+}
+
+func (r RecentlyViewedUsecase) Record(ctx context.Context, userID string, courseID string) error {
+
+	if !primitive.IsValidObjectID(courseID) {
+		return domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
+	}
+
+	err := r.DBRepository.Record(ctx, userID, models.GenerateObjectIDFromHex(courseID), time.Now())
+	if err != nil {
+		log.Println("RECENTLY VIEWED USECASE: Record >>", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r RecentlyViewedUsecase) Fetch(ctx context.Context, userID string, request *requests.RecentlyViewedRequest) (models.RecentlyViewed, error) {
+
+	//1. A user without a list viewed nothing yet
+	recentlyViewed, err := r.DBRepository.FetchByUserId(ctx, userID, request.Limit)
+	if errors.Is(err, domain_errors.ErrNotFound) {
+		return models.RecentlyViewed{UserID: userID, Courses: []models.ViewedCourse{}}, nil
+	}
+	if err != nil {
+		log.Println("RECENTLY VIEWED USECASE: Fetch >>", err)
+		return models.RecentlyViewed{}, err
+	}
+
+	//2. Attach course names from CourseService through GRPC, the view order is kept
+	courses := make([]models.Course, 0, len(recentlyViewed.Courses))
+	for _, viewed := range recentlyViewed.Courses {
+		courses = append(courses, models.Course{ID: viewed.ID})
+	}
+
+	courses, err = attachCourseNames(ctx, r.GRPCCourseServiceClient, courses)
+	if err != nil {
+		log.Println("RECENTLY VIEWED USECASE: Fetch: Attach Names >>", err)
+		return models.RecentlyViewed{}, err
+	}
+
+	for i := range recentlyViewed.Courses {
+		recentlyViewed.Courses[i].Name = courses[i].Name
+	}
+
+	return recentlyViewed, nil
+}
+
+func ConstructRecentlyViewedUsecase(DBRepository contracts.RecentlyViewedDBRepository, grpcCourseService contracts.GRPCCourseService) contracts.RecentlyViewedUsecase {
+	return &RecentlyViewedUsecase{DBRepository: DBRepository, GRPCCourseServiceClient: grpcCourseService}
+}
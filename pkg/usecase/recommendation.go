@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+const (
+	defaultRecommendationTTL   = time.Hour
+	defaultRecommendationLimit = 10
+	// cachedRecommendations are computed once, the user's current courses and the limit are applied to them on every read
+	cachedRecommendations = 100
+)
+
+type RecommendationUsecase struct {
+	DBRepository            contracts.RecommendationDBRepository
+	GRPCCourseServiceClient contracts.GRPCCourseService
+	// TTL is how long a recommendation is served from the cache, changes of the user's courses show after it
+	TTL time.Duration
+}
+
+func (r RecommendationUsecase) Recommend(ctx context.Context, userID string, request *requests.RecommendationRequest) ([]models.RecommendedCourse, error) {
+
+	//1. The user's current courses are left out of the cached courses, they may have changed since it was computed
+	ownedCourses, err := r.DBRepository.OwnedCourses(ctx, userID)
+	if err != nil {
+		log.Println("RECOMMENDATION USECASE: Recommend >>", err)
+		return nil, err
+	}
+	owned := make(map[primitive.ObjectID]bool, len(ownedCourses))
+	for _, id := range ownedCourses {
+		owned[id] = true
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultRecommendationLimit
+	}
+
+	//2. Serve the cache, compute and cache it when it expired
+	recommendation, err := r.DBRepository.FetchCached(ctx, userID)
+	if errors.Is(err, domain_errors.ErrNotFound) {
+		recommendation, err = r.compute(ctx, userID)
+	}
+	if err != nil {
+		log.Println("RECOMMENDATION USECASE: Recommend >>", err)
+		return nil, err
+	}
+
+	courses := make([]models.RecommendedCourse, 0, limit)
+	for _, course := range recommendation.Courses {
+		if int64(len(courses)) == limit {
+			break
+		}
+		if !owned[course.ID] {
+			courses = append(courses, course)
+		}
+	}
+
+	//3. Attach course names from CourseService through GRPC, the ranking is kept
+	return r.attachCourseNames(ctx, courses)
+}
+
+func (r RecommendationUsecase) compute(ctx context.Context, userID string) (models.Recommendation, error) {
+
+	courses, err := r.DBRepository.Compute(ctx, userID, cachedRecommendations)
+	if err != nil {
+		return models.Recommendation{}, err
+	}
+
+	now := time.Now()
+	recommendation := models.Recommendation{UserID: userID, Courses: courses, CreatedAt: now, ExpiresAt: now.Add(r.TTL)}
+
+	//a recommendation which can't be cached is still served
+	if err = r.DBRepository.Cache(ctx, &recommendation); err != nil {
+		log.Println("RECOMMENDATION USECASE: Cache >>", err)
+	}
+
+	return recommendation, nil
+}
+
+func (r RecommendationUsecase) attachCourseNames(ctx context.Context, recommended []models.RecommendedCourse) ([]models.RecommendedCourse, error) {
+
+	courses := make([]models.Course, 0, len(recommended))
+	for _, course := range recommended {
+		courses = append(courses, models.Course{ID: course.ID})
+	}
+
+	courses, err := attachCourseNames(ctx, r.GRPCCourseServiceClient, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range recommended {
+		recommended[i].Name = courses[i].Name
+	}
+
+	return recommended, nil
+}
+
+// ConstructRecommendationUsecase a zero 'ttl' caches recommendations for an hour
+func ConstructRecommendationUsecase(DBRepository contracts.RecommendationDBRepository, grpcCourseService contracts.GRPCCourseService, ttl time.Duration) contracts.RecommendationUsecase {
+	if ttl <= 0 {
+		ttl = defaultRecommendationTTL
+	}
+	return &RecommendationUsecase{DBRepository: DBRepository, GRPCCourseServiceClient: grpcCourseService, TTL: ttl}
+}
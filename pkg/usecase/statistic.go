@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"log"
+)
+
+const defaultTopCoursesLimit = 10
+
+type StatisticUsecase struct {
+	DBRepository            contracts.StatisticDBRepository
+	GRPCCourseServiceClient contracts.GRPCCourseService
+}
+
+func (s StatisticUsecase) CountByCourses(ctx context.Context, coursesID []string) (statistics []models.CourseStatistic, err error) {
+
+	if len(coursesID) == 0 {
+		return nil, domain_errors.InvalidArgument("you don't provide any course id", nil)
+	}
+
+	statistics, err = s.DBRepository.CountByCourses(ctx, coursesID)
+	if err != nil {
+		log.Println("STATISTIC USECASE: CountByCourses ERROR >>", err)
+		return nil, err
+	}
+
+	return s.attachCourseNames(ctx, statistics)
+}
+
+func (s StatisticUsecase) TopCourses(ctx context.Context, request *requests.TopCoursesRequest) (statistics []models.CourseStatistic, err error) {
+
+	source := request.By
+	if source == "" {
+		source = models.StatisticSourceBookmarks
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultTopCoursesLimit
+	}
+
+	statistics, err = s.DBRepository.TopCourses(ctx, source, limit, request.Since, request.Until)
+	if err != nil {
+		log.Println("STATISTIC USECASE: TopCourses ERROR >>", err)
+		return nil, err
+	}
+
+	return s.attachCourseNames(ctx, statistics)
+}
+
+// attachCourseNames fetch course names from CourseService through GRPC
+func (s StatisticUsecase) attachCourseNames(ctx context.Context, statistics []models.CourseStatistic) ([]models.CourseStatistic, error) {
+
+	if len(statistics) == 0 {
+		return statistics, nil
+	}
+
+	cIDs := make([]string, 0)
+	for _, statistic := range statistics {
+		cIDs = append(cIDs, statistic.CourseID.Hex())
+	}
+
+	courses, err := s.GRPCCourseServiceClient.List(ctx, cIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, course := range courses {
+		names[course.ID.Hex()] = course.Name
+	}
+
+	for i := range statistics {
+		statistics[i].Name = names[statistics[i].CourseID.Hex()]
+	}
+
+	return statistics, nil
+}
+
+func ConstructStatisticUsecase(DBRepository contracts.StatisticDBRepository, grpcCourseService contracts.GRPCCourseService) contracts.StatisticUsecase {
+	return &StatisticUsecase{DBRepository: DBRepository, GRPCCourseServiceClient: grpcCourseService}
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/contracts"
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/http/requests"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"strings"
+)
+
+// defaultTagSuggestions is the number of tags suggested when the request has no limit
+const defaultTagSuggestions = 10
+
+type TagUsecase struct {
+	DBRepository            contracts.TagDBRepository
+	GRPCCourseServiceClient contracts.GRPCCourseService
+}
+
+func (t TagUsecase) FetchByName(ctx context.Context, name string) (tag models.Tag, err error) {
+
+	name, err = tagName(name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	tag, err = t.DBRepository.FetchByName(ctx, name)
+	if err != nil {
+		log.Println("TAG USECASE: FetchByName ERROR >>", err)
+		return models.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (t TagUsecase) SetParent(ctx context.Context, name string, request *requests.TagParentRequest) (status bool, err error) {
+
+	//1. Both sides are resolved through the synonyms
+	tag, err := t.FetchByName(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	var parent *primitive.ObjectID
+	if strings.TrimSpace(request.Parent) != "" {
+		parentTag, err := t.FetchByName(ctx, request.Parent)
+		if err != nil {
+			return false, err
+		}
+		parent = &parentTag.ID
+	}
+
+	//2. The repository refuses a parent which would make a cycle
+	err = t.DBRepository.SetParent(ctx, tag.ID, parent)
+	if err != nil {
+		log.Println("TAG USECASE: SetParent ERROR >>", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (t TagUsecase) AddSynonym(ctx context.Context, name string, synonym string) (status bool, err error) {
+
+	synonym, err = tagName(synonym)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := t.FetchByName(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	err = t.DBRepository.AddSynonym(ctx, tag.ID, strings.ToLower(synonym))
+	if err != nil {
+		log.Println("TAG USECASE: AddSynonym ERROR >>", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (t TagUsecase) RemoveSynonym(ctx context.Context, name string, synonym string) (status bool, err error) {
+
+	tag, err := t.FetchByName(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	err = t.DBRepository.RemoveSynonym(ctx, tag.ID, strings.ToLower(strings.TrimSpace(synonym)))
+	if err != nil {
+		log.Println("TAG USECASE: RemoveSynonym ERROR >>", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (t TagUsecase) AttachCourse(ctx context.Context, name string, courseID string) (tag models.Tag, err error) {
+
+	name, err = tagName(name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	if !primitive.IsValidObjectID(courseID) {
+		return models.Tag{}, domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
+	}
+
+	tag, err = t.DBRepository.AttachCourse(ctx, name, models.GenerateObjectIDFromHex(courseID))
+	if err != nil {
+		log.Println("TAG USECASE: AttachCourse ERROR >>", err)
+		return models.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (t TagUsecase) Search(ctx context.Context, request *requests.TagSearchRequest) (tags []models.TagSuggestion, err error) {
+
+	prefix := models.TagSearchName(request.Q)
+	if prefix == "" {
+		return nil, domain_errors.InvalidArgument("q must have a character other than spaces", nil)
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultTagSuggestions
+	}
+
+	tags, err = t.DBRepository.Search(ctx, prefix, limit)
+	if err != nil {
+		log.Println("TAG USECASE: Search ERROR >>", err)
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// tagName a catalogue tag keeps its case, only the surrounding spaces are trimmed
+func tagName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" || len(name) > 64 {
+		return "", domain_errors.InvalidArgument(fmt.Sprintf("tag %q must be between 1 and 64 characters", raw), nil)
+	}
+	return name, nil
+}
+
+func ConstructTagUsecase(DBRepository contracts.TagDBRepository, grpcCourseService contracts.GRPCCourseService) contracts.TagUsecase {
+	return &TagUsecase{DBRepository: DBRepository, GRPCCourseServiceClient: grpcCourseService}
+}
@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"acourse_tag_cart_bookmark_service/pkg/domain_errors"
+	"acourse_tag_cart_bookmark_service/pkg/models"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"log"
+	"strings"
+)
+
+const (
+	// maxTagImportLines is the most lines a single import applies
+	maxTagImportLines = 10000
+	// maxTagImportLineBytes is the longest jsonl line which is parsed
+	maxTagImportLineBytes = 64 << 10
+)
+
+// importLine is a parsed line of an import, 'err' tells why it can't be applied
+type importLine struct {
+	number     int
+	assignment models.TagAssignment
+	err        error
+}
+
+func (t TagUsecase) Import(ctx context.Context, reader io.Reader, format string) (report models.TagImportReport, err error) {
+
+	//1. Parse the lines, a malformed line fails alone
+	var lines []importLine
+	switch format {
+	case "csv":
+		lines, err = parseCSVAssignments(reader)
+	case "jsonl":
+		lines, err = parseJSONLAssignments(reader)
+	default:
+		return models.TagImportReport{}, domain_errors.InvalidArgument(fmt.Sprintf("format %q must be csv or jsonl", format), nil)
+	}
+	if err != nil {
+		return models.TagImportReport{}, err
+	}
+
+	//2. Check the course ids and the tag names
+	coursesID := make([]string, 0)
+	for i, line := range lines {
+		if line.err != nil {
+			continue
+		}
+
+		if !primitive.IsValidObjectID(line.assignment.CourseID) {
+			lines[i].err = domain_errors.InvalidArgument("course_id must be a 24 characters hex string", nil)
+			continue
+		}
+
+		lines[i].assignment.Tag, lines[i].err = tagName(line.assignment.Tag)
+		if lines[i].err == nil {
+			coursesID = append(coursesID, line.assignment.CourseID)
+		}
+	}
+
+	//3. A course unknown to the Course service can't be tagged
+	known := make(map[primitive.ObjectID]bool)
+	if len(coursesID) > 0 {
+		courses, err := t.GRPCCourseServiceClient.List(ctx, distinct(coursesID))
+		if err != nil {
+			log.Println("TAG USECASE: Import: List Courses ERROR >>", err)
+			return models.TagImportReport{}, err
+		}
+		for _, course := range courses {
+			known[course.ID] = true
+		}
+	}
+
+	//4. Group the courses by tag, a tag is a single write
+	var tags []models.TagCourses
+	tagIndex := make(map[string]int)
+	lineTag := make(map[int]int)
+	for i, line := range lines {
+		if line.err != nil {
+			continue
+		}
+
+		courseID := models.GenerateObjectIDFromHex(line.assignment.CourseID)
+		if !known[courseID] {
+			lines[i].err = domain_errors.NotFound("course "+line.assignment.CourseID+" doesn't exist", nil)
+			continue
+		}
+
+		index, ok := tagIndex[line.assignment.Tag]
+		if !ok {
+			index = len(tags)
+			tagIndex[line.assignment.Tag] = index
+			tags = append(tags, models.TagCourses{Name: line.assignment.Tag})
+		}
+		tags[index].Courses = append(tags[index].Courses, courseID)
+		lineTag[i] = index
+	}
+
+	//5. Apply and report each line with the outcome of its tag
+	if len(tags) > 0 {
+		failures, err := t.DBRepository.AttachCourses(ctx, tags)
+		if err != nil {
+			log.Println("TAG USECASE: Import ERROR >>", err)
+			return models.TagImportReport{}, err
+		}
+		for i, index := range lineTag {
+			lines[i].err = failures[index]
+		}
+	}
+
+	report.Lines = make([]models.TagImportLine, 0, len(lines))
+	for _, line := range lines {
+		reported := models.TagImportLine{Line: line.number, CourseID: line.assignment.CourseID, Tag: line.assignment.Tag, Applied: line.err == nil}
+		if line.err != nil {
+			reported.Error = domain_errors.Message(line.err)
+			report.Failed++
+		} else {
+			report.Applied++
+		}
+		report.Lines = append(report.Lines, reported)
+	}
+
+	return report, nil
+}
+
+// parseCSVAssignments read 'course_id,tag' records, a first record naming the columns is skipped;
+// a malformed record fails alone, an unterminated quote takes the rest of the body with it
+func parseCSVAssignments(reader io.Reader) ([]importLine, error) {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	lines := make([]importLine, 0)
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var line importLine
+		var parseError *csv.ParseError
+		switch {
+		case errors.As(err, &parseError):
+			line = importLine{number: parseError.StartLine, err: domain_errors.InvalidArgument("line is malformed: "+parseError.Err.Error(), err)}
+		case err != nil:
+			return nil, readError(err)
+		default:
+			number, _ := csvReader.FieldPos(0)
+			if len(lines) == 0 && number == 1 && len(record) == 2 && record[0] == "course_id" && record[1] == "tag" {
+				continue
+			}
+
+			line = importLine{number: number}
+			if len(record) != 2 {
+				line.err = domain_errors.InvalidArgument(fmt.Sprintf("a line must have 2 fields, course_id and tag, it has %d", len(record)), nil)
+			} else {
+				line.assignment = models.TagAssignment{CourseID: strings.TrimSpace(record[0]), Tag: record[1]}
+			}
+		}
+
+		lines = append(lines, line)
+		if len(lines) > maxTagImportLines {
+			return nil, domain_errors.InvalidArgument(fmt.Sprintf("an import has at most %d lines", maxTagImportLines), nil)
+		}
+	}
+
+	return lines, nil
+}
+
+// parseJSONLAssignments read one {"course_id": "...", "tag": "..."} object per line, blank lines are skipped;
+// a line which isn't such an object or is longer than maxTagImportLineBytes fails alone
+func parseJSONLAssignments(reader io.Reader) ([]importLine, error) {
+
+	bufferedReader := bufio.NewReader(reader)
+
+	lines := make([]importLine, 0)
+	for number := 1; ; number++ {
+		text, tooLong, err := readJSONLLine(bufferedReader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, readError(err)
+		}
+
+		line := importLine{number: number}
+		switch {
+		case tooLong:
+			line.err = domain_errors.InvalidArgument(fmt.Sprintf("line is longer than %d bytes", maxTagImportLineBytes), nil)
+		case strings.TrimSpace(text) == "":
+			continue
+		default:
+			err = json.Unmarshal([]byte(text), &line.assignment)
+			if err != nil {
+				line.err = domain_errors.InvalidArgument("line is not a json object with course_id and tag", err)
+			}
+			line.assignment.CourseID = strings.TrimSpace(line.assignment.CourseID)
+		}
+
+		lines = append(lines, line)
+		if len(lines) > maxTagImportLines {
+			return nil, domain_errors.InvalidArgument(fmt.Sprintf("an import has at most %d lines", maxTagImportLines), nil)
+		}
+	}
+
+	return lines, nil
+}
+
+// readJSONLLine read the next line without its end of line, the rest of a line longer than maxTagImportLineBytes is skipped;
+// io.EOF is returned once there are no more lines
+func readJSONLLine(reader *bufio.Reader) (text string, tooLong bool, err error) {
+
+	var line []byte
+	read := false
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) && read {
+				return string(line), tooLong, nil
+			}
+			return "", false, err
+		}
+		read = true
+
+		if len(line)+len(chunk) > maxTagImportLineBytes {
+			tooLong = true
+			line = nil
+		} else if !tooLong {
+			line = append(line, chunk...)
+		}
+
+		if !isPrefix {
+			return string(line), tooLong, nil
+		}
+	}
+}
+
+// readError a body which can't be read is an invalid argument, unless the reader tells why
+func readError(err error) error {
+	var domainError *domain_errors.Error
+	if errors.As(err, &domainError) {
+		return err
+	}
+	return domain_errors.InvalidArgument("body can't be read: "+err.Error(), err)
+}
+
+// distinct keep the first occurrence of each id
+func distinct(ids []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}